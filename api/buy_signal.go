@@ -1,6 +1,62 @@
 package api
 
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownSignalStage = errors.New("unknown signal stage")
+	ErrUnknownSignalSide  = errors.New("unknown signal side")
+)
+
+// SignalStage tells which announcement of a listing produced the signal.
+type SignalStage string
+
+const (
+	// SignalStageUnspecified is used by sources that announce a listing only once.
+	SignalStageUnspecified SignalStage = ""
+	// SignalStageTransfers is the announcement that inbound transfers of the coin are available.
+	SignalStageTransfers SignalStage = "transfers"
+	// SignalStageTradingLive is the announcement that trading of the coin has begun.
+	SignalStageTradingLive SignalStage = "trading_live"
+)
+
+// SignalSide tells whether the signal is to go long or short, buy if it is empty.
+type SignalSide string
+
+const (
+	SignalSideBuy  SignalSide = "buy"
+	SignalSideSell SignalSide = "sell"
+)
+
+// BuySignal is a signal to trade the symbol, it is a buy signal unless Side is sell,
+// e.g. from a delisting announcement.
 type BuySignal struct {
-	Symbol string `json:"symbol"`
-	Source string `json:"source"`
+	Symbol string      `json:"symbol"`
+	Source string      `json:"source"`
+	Stage  SignalStage `json:"stage,omitempty"`
+	Side   SignalSide  `json:"side,omitempty"`
+}
+
+func (s BuySignal) IsSell() bool {
+	return s.Side == SignalSideSell
+}
+
+func ParseSignalStage(s string) (SignalStage, error) {
+	switch stage := SignalStage(s); stage {
+	case SignalStageTransfers, SignalStageTradingLive:
+		return stage, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownSignalStage, s)
+	}
+}
+
+func ParseSignalSide(s string) (SignalSide, error) {
+	switch side := SignalSide(s); side {
+	case SignalSideBuy, SignalSideSell:
+		return side, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownSignalSide, s)
+	}
 }
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SignalVersion is the version of the Signal JSON written by this package.
+const SignalVersion = 1
+
+var (
+	ErrUnsupportedSignalVersion = errors.New("unsupported signal version")
+	ErrInvalidSignal            = errors.New("invalid signal")
+)
+
+// SourceKind is the kind of source that detected a signal.
+type SourceKind string
+
+const (
+	SourceKindUnknown      SourceKind = "unknown"
+	SourceKindTwitter      SourceKind = "twitter"
+	SourceKindAnnouncement SourceKind = "announcement"
+	SourceKindWebhook      SourceKind = "webhook"
+	SourceKindTelegram     SourceKind = "telegram"
+	SourceKindFeed         SourceKind = "feed"
+)
+
+// Signal is the versioned signal model, it carries what a consumer needs beyond the symbol to decide
+// how much to trade and whether the signal is still fresh.
+type Signal struct {
+	Version    int         `json:"version"`
+	Symbol     string      `json:"symbol"`
+	Side       SignalSide  `json:"side"`
+	Stage      SignalStage `json:"stage,omitempty"`
+	SourceKind SourceKind  `json:"sourceKind"`
+	Source     string      `json:"source"`
+	// DetectedAt is when the source detected the signal.
+	DetectedAt time.Time `json:"detectedAt"`
+	// EventAt is when the original event, e.g. the tweet, was published, zero if unknown.
+	EventAt time.Time `json:"eventAt"`
+	// Confidence is in [0, 1], e.g. the similarity of the tweet to the matched pattern.
+	Confidence float64           `json:"confidence"`
+	RawText    string            `json:"rawText,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// NewSignal converts the buy signal into the versioned model.
+func NewSignal(b BuySignal, kind SourceKind, detectedAt time.Time) Signal {
+	side := b.Side
+	if side == "" {
+		side = SignalSideBuy
+	}
+
+	return Signal{
+		Version:    SignalVersion,
+		Symbol:     b.Symbol,
+		Side:       side,
+		Stage:      b.Stage,
+		SourceKind: kind,
+		Source:     b.Source,
+		DetectedAt: detectedAt,
+		Confidence: 1,
+	}
+}
+
+// BuySignal converts the signal into the model consumed by the trading managers.
+func (s Signal) BuySignal() BuySignal {
+	return BuySignal{
+		Symbol: s.Symbol,
+		Source: s.Source,
+		Stage:  s.Stage,
+		Side:   s.Side,
+	}
+}
+
+// Latency is the time from the original event to the detection, zero if the event time is unknown.
+func (s Signal) Latency() time.Duration {
+	if s.EventAt.IsZero() || s.DetectedAt.IsZero() {
+		return 0
+	}
+
+	return s.DetectedAt.Sub(s.EventAt)
+}
+
+func (s Signal) Validate() error {
+	if strings.TrimSpace(s.Symbol) == "" {
+		return fmt.Errorf("%w: empty symbol", ErrInvalidSignal)
+	}
+
+	if _, err := ParseSignalSide(string(s.Side)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignal, err) // nolint: errorlint
+	}
+
+	if s.Confidence < 0 || s.Confidence > 1 {
+		return fmt.Errorf("%w: confidence %v out of [0, 1]", ErrInvalidSignal, s.Confidence)
+	}
+
+	return nil
+}
+
+// UnmarshalJSON rejects versions newer than SignalVersion, and reads a JSON without version, i.e. a
+// BuySignal, as a version 1 buy signal.
+func (s *Signal) UnmarshalJSON(b []byte) error {
+	type signal Signal
+
+	var v signal
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("unmarshal signal: %w", err)
+	}
+
+	switch {
+	case v.Version > SignalVersion:
+		return fmt.Errorf("%w: %d", ErrUnsupportedSignalVersion, v.Version)
+	case v.Version == 0:
+		v.Version = SignalVersion
+
+		if v.Side == "" {
+			v.Side = SignalSideBuy
+		}
+
+		if v.SourceKind == "" {
+			v.SourceKind = SourceKindUnknown
+		}
+	}
+
+	*s = Signal(v)
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalJSONRoundTrip(t *testing.T) {
+	eventAt := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	testCases := []Signal{
+		{
+			Version:    SignalVersion,
+			Symbol:     "SHIB",
+			Side:       SignalSideBuy,
+			Stage:      SignalStageTransfers,
+			SourceKind: SourceKindTwitter,
+			Source:     "https://twitter.com/CoinbasePro/status/1405557367326023680",
+			DetectedAt: eventAt.Add(850 * time.Millisecond),
+			EventAt:    eventAt,
+			Confidence: 0.82,
+			RawText:    "Inbound transfers for CHZ, KEEP & SHIB are now available",
+			Metadata:   map[string]string{"pattern": "coinbase_inbound_transfers", "account": "720487892670410753"},
+		},
+		{
+			Version:    SignalVersion,
+			Symbol:     "TORN",
+			Side:       SignalSideSell,
+			SourceKind: SourceKindAnnouncement,
+			Source:     "https://www.binance.com/en/support/announcement/1",
+			DetectedAt: eventAt,
+			Confidence: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			b, err := json.Marshal(tc)
+			require.NoError(t, err)
+
+			var got Signal
+			require.NoError(t, json.Unmarshal(b, &got))
+
+			assert.True(t, tc.DetectedAt.Equal(got.DetectedAt))
+			assert.True(t, tc.EventAt.Equal(got.EventAt))
+
+			got.DetectedAt, got.EventAt = tc.DetectedAt, tc.EventAt
+			assert.Equal(t, tc, got)
+			assert.NoError(t, got.Validate())
+		})
+	}
+}
+
+func TestSignalUnmarshalVersion(t *testing.T) {
+	var s Signal
+
+	require.NoError(t, json.Unmarshal([]byte(`{"symbol":"SOL","source":"https://twitter.com/CoinbasePro/status/1"}`), &s))
+	assert.Equal(t, SignalVersion, s.Version)
+	assert.Equal(t, SignalSideBuy, s.Side)
+	assert.Equal(t, SourceKindUnknown, s.SourceKind)
+	assert.Equal(t, BuySignal{Symbol: "SOL", Source: "https://twitter.com/CoinbasePro/status/1", Side: SignalSideBuy}, s.BuySignal())
+
+	err := json.Unmarshal([]byte(`{"version":2,"symbol":"SOL"}`), &s)
+	assert.ErrorIs(t, err, ErrUnsupportedSignalVersion)
+}
+
+func TestSignalValidate(t *testing.T) {
+	valid := NewSignal(BuySignal{Symbol: "SOL"}, SourceKindWebhook, time.Now())
+	require.NoError(t, valid.Validate())
+
+	testCases := []func(s *Signal){
+		func(s *Signal) { s.Symbol = " " },
+		func(s *Signal) { s.Side = "hold" },
+		func(s *Signal) { s.Confidence = 1.5 },
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			s := valid
+			tc(&s)
+			assert.ErrorIs(t, s.Validate(), ErrInvalidSignal)
+		})
+	}
+}
+
+func TestSignalLatency(t *testing.T) {
+	eventAt := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 2*time.Second, Signal{EventAt: eventAt, DetectedAt: eventAt.Add(2 * time.Second)}.Latency())
+	assert.Zero(t, Signal{DetectedAt: eventAt}.Latency())
+}
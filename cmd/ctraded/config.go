@@ -8,15 +8,27 @@ import (
 	"time"
 
 	"github.com/dghubble/oauth1"
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/lht102/ctrade/pkg/feed"
 	"github.com/lht102/ctrade/pkg/trading"
+	"github.com/lht102/ctrade/pkg/tweet"
 	"github.com/spf13/viper"
-	coingecko "github.com/superoo7/go-gecko/v3"
+	"go.uber.org/zap"
 )
 
 const (
-	longHTTPTimeout  = 30 * time.Second
-	shortHTTPTimeout = 5 * time.Second
-	updateBinanceExchangeInfoInterval = 15 * time.Minute
+	longHTTPTimeout                        = 30 * time.Second
+	shortHTTPTimeout                       = 5 * time.Second
+	updateBinanceExchangeInfoInterval      = 15 * time.Minute
+	paperPriceFeedInterval                 = 5 * time.Second
+	checkExitOrdersInterval                = 10 * time.Second
+	checkPositionDeadlinesInterval         = 30 * time.Second
+	defaultSignalDedupWindow               = 24 * time.Hour
+	defaultBinanceAnnouncementPollInterval = 30 * time.Second
+	defaultWebhookAddr                     = ":8080"
+	defaultFeedPollInterval                = time.Minute
+	defaultCoinRefreshInterval             = time.Hour
 )
 
 var (
@@ -26,12 +38,21 @@ var (
 	errEmptyTwitterAccessTokenSecret = errors.New("empty twitter access token secret")
 	errEmptyBinanceAPIKey            = errors.New("empty binacne api key")
 	errEmptyBinanceAPISecretKey      = errors.New("empty binacne api secret key")
+	errEmptyWebhookSecret            = errors.New("empty webhook secret")
+	errEmptyTelegramBotToken         = errors.New("empty telegram bot token")
+	errEmptyTelegramPatternFile      = errors.New("empty telegram pattern file")
+	errEmptyFeedFile                 = errors.New("empty feed file")
 )
 
 func getEnv(v *viper.Viper) string {
 	return v.GetString("ENV")
 }
 
+// getTwitterBearerToken returns the app bearer token of the API v2 filtered stream, empty to use the v1.1 filter stream.
+func getTwitterBearerToken(v *viper.Viper) string {
+	return v.GetString("TWITTER_BEARER_TOKEN")
+}
+
 func getTwitterClientCredentialsConfig(v *viper.Viper) (*oauth1.Config, error) {
 	twitterAPIKey := v.GetString("TWITTER_API_KEY")
 	if twitterAPIKey == "" {
@@ -78,7 +99,7 @@ func getBinanceAPISecretKey(v *viper.Viper) (string, error) {
 	return binanceAPISecretKey, nil
 }
 
-func getFuturesOptions(v *viper.Viper) []trading.FuturesOption {
+func getFuturesOptions(v *viper.Viper) ([]trading.FuturesOption, error) {
 	var opts []trading.FuturesOption
 
 	willExecuteOrder := v.GetBool("WILL_EXECUTE_ORDER")
@@ -86,6 +107,54 @@ func getFuturesOptions(v *viper.Viper) []trading.FuturesOption {
 		opts = append(opts, trading.WithWillExecuteOrder(willExecuteOrder))
 	}
 
+	if sizingModeStr := v.GetString("FUTURES_SIZING_MODE"); sizingModeStr != "" {
+		sizingMode, err := trading.ParseSizingMode(sizingModeStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse futures sizing mode: %w", err)
+		}
+
+		opts = append(opts, trading.WithSizingMode(sizingMode))
+	}
+
+	balancePercentage := v.GetFloat64("FUTURES_BALANCE_PERCENTAGE")
+	if balancePercentage > 0 {
+		opts = append(opts, trading.WithBalancePercentage(balancePercentage))
+	}
+
+	riskPerTradeInUSD := v.GetFloat64("FUTURES_RISK_PER_TRADE_IN_USD")
+	if riskPerTradeInUSD > 0 {
+		opts = append(opts, trading.WithRiskPerTradeInUSD(riskPerTradeInUSD))
+	}
+
+	maxNotionalInUSD := v.GetFloat64("FUTURES_MAX_NOTIONAL_IN_USD")
+	if maxNotionalInUSD > 0 {
+		opts = append(opts, trading.WithMaxNotionalInUSD(maxNotionalInUSD))
+	}
+
+	if entryModeStr := v.GetString("FUTURES_ENTRY_MODE"); entryModeStr != "" {
+		entryMode, err := trading.ParseEntryMode(entryModeStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse futures entry mode: %w", err)
+		}
+
+		opts = append(opts, trading.WithEntryMode(entryMode))
+	}
+
+	entryMaxSlippagePercentage := v.GetFloat64("FUTURES_ENTRY_MAX_SLIPPAGE_PERCENTAGE")
+	if entryMaxSlippagePercentage != 0 {
+		opts = append(opts, trading.WithEntryMaxSlippagePercentage(entryMaxSlippagePercentage))
+	}
+
+	entryRequotes := v.GetInt("FUTURES_ENTRY_REQUOTES")
+	if entryRequotes > 0 {
+		opts = append(opts, trading.WithEntryRequotes(entryRequotes))
+	}
+
+	entryRequoteInterval := v.GetDuration("FUTURES_ENTRY_REQUOTE_INTERVAL")
+	if entryRequoteInterval > 0 {
+		opts = append(opts, trading.WithEntryRequoteInterval(entryRequoteInterval))
+	}
+
 	leverage := v.GetInt("FUTURES_LEVERAGE")
 	if leverage > 0 {
 		opts = append(opts, trading.WithLeverage(leverage))
@@ -101,23 +170,303 @@ func getFuturesOptions(v *viper.Viper) []trading.FuturesOption {
 		opts = append(opts, trading.WithTakeProfitPriceChangedPercentage(takeProfitPriceChangedPercentage))
 	}
 
-	return opts
+	stopLossPriceChangedPercentage := v.GetFloat64("FUTURES_STOP_LOSS_PRICE_CHANGED_PERCENTAGE")
+	if stopLossPriceChangedPercentage > 0 {
+		opts = append(opts, trading.WithStopLossPriceChangedPercentage(stopLossPriceChangedPercentage))
+	}
+
+	if takeProfitLadderStr := v.GetString("FUTURES_TAKE_PROFIT_LADDER"); takeProfitLadderStr != "" {
+		takeProfitLadder, err := trading.ParseTakeProfitLadder(takeProfitLadderStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse futures take profit ladder: %w", err)
+		}
+
+		opts = append(opts, trading.WithTakeProfitLadder(takeProfitLadder...))
+	}
+
+	if exitModeStr := v.GetString("FUTURES_EXIT_MODE"); exitModeStr != "" {
+		exitMode, err := trading.ParseExitMode(exitModeStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse futures exit mode: %w", err)
+		}
+
+		opts = append(opts, trading.WithExitMode(exitMode))
+	}
+
+	trailingStopCallbackRate := v.GetFloat64("FUTURES_TRAILING_STOP_CALLBACK_RATE")
+	if trailingStopCallbackRate > 0 {
+		opts = append(opts, trading.WithTrailingStopCallbackRate(trailingStopCallbackRate))
+	}
+
+	trailingStopActivationPercentage := v.GetFloat64("FUTURES_TRAILING_STOP_ACTIVATION_PRICE_CHANGED_PERCENTAGE")
+	if trailingStopActivationPercentage > 0 {
+		opts = append(opts, trading.WithTrailingStopActivationPriceChangedPercentage(trailingStopActivationPercentage))
+	}
+
+	maxHoldingDuration := v.GetDuration("FUTURES_MAX_HOLDING_DURATION")
+	if maxHoldingDuration > 0 {
+		opts = append(opts, trading.WithMaxHoldingDuration(maxHoldingDuration))
+	}
+
+	if entryStagesStr := v.GetString("FUTURES_ENTRY_STAGES"); entryStagesStr != "" {
+		entryStages, err := parseSignalStages(entryStagesStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse futures entry stages: %w", err)
+		}
+
+		opts = append(opts, trading.WithEntryStages(entryStages...))
+	}
+
+	if addOnStagesStr := v.GetString("FUTURES_ADD_ON_STAGES"); addOnStagesStr != "" {
+		addOnStages, err := parseSignalStages(addOnStagesStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse futures add on stages: %w", err)
+		}
+
+		opts = append(opts, trading.WithAddOnStages(addOnStages...))
+	}
+
+	if shortSelling := v.GetBool("FUTURES_SHORT_SELLING"); shortSelling {
+		opts = append(opts, trading.WithShortSelling(shortSelling))
+	}
+
+	if quoteAssets := splitList(strings.ToUpper(v.GetString("FUTURES_QUOTE_ASSETS"))); len(quoteAssets) > 0 {
+		opts = append(opts, trading.WithQuoteAssets(quoteAssets...))
+	}
+
+	if deadlineFile := v.GetString("POSITION_DEADLINE_FILE"); deadlineFile != "" {
+		opts = append(opts, trading.WithDeadlineStore(trading.NewFileDeadlineStore(deadlineFile)))
+	}
+
+	return opts, nil
+}
+
+// parseSignalStages parses a comma separated list of signal stages, e.g. "transfers,trading_live".
+func parseSignalStages(s string) ([]api.SignalStage, error) {
+	res := []api.SignalStage{}
+
+	for _, str := range strings.Split(s, ",") {
+		stage, err := api.ParseSignalStage(strings.TrimSpace(str))
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, stage)
+	}
+
+	return res, nil
+}
+
+func getSignalDedupWindow(v *viper.Viper) time.Duration {
+	if window := v.GetDuration("SIGNAL_DEDUP_WINDOW"); window > 0 {
+		return window
+	}
+
+	return defaultSignalDedupWindow
+}
+
+// getSignalSources returns the names of the enabled signal sources, only twitter if not set.
+func getSignalSources(v *viper.Viper) []string {
+	res := []string{}
+
+	for _, name := range strings.Split(v.GetString("SIGNAL_SOURCES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			res = append(res, name)
+		}
+	}
+
+	if len(res) == 0 {
+		return []string{tweet.SourceName}
+	}
+
+	return res
 }
 
-func getSupportedCoins() (map[string]struct{}, error) {
-	coingeckoClient := coingecko.NewClient(&http.Client{
-		Timeout: longHTTPTimeout,
-	})
+func getBinanceAnnouncementPollInterval(v *viper.Viper) time.Duration {
+	if d := v.GetDuration("BINANCE_ANNOUNCEMENT_POLL_INTERVAL"); d > 0 {
+		return d
+	}
+
+	return defaultBinanceAnnouncementPollInterval
+}
+
+func getWebhookAddr(v *viper.Viper) string {
+	if addr := v.GetString("WEBHOOK_ADDR"); addr != "" {
+		return addr
+	}
+
+	return defaultWebhookAddr
+}
+
+func getWebhookSecret(v *viper.Viper) (string, error) {
+	secret := v.GetString("WEBHOOK_SECRET")
+	if secret == "" {
+		return "", errEmptyWebhookSecret
+	}
 
-	coins, err := coingeckoClient.CoinsList()
+	return secret, nil
+}
+
+// getTweetPatternLibrary loads TWEET_PATTERN_FILE, nil if not set.
+func getTweetPatternLibrary(v *viper.Viper) (*tweet.PatternLibrary, error) {
+	path := v.GetString("TWEET_PATTERN_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	l, err := tweet.LoadPatternLibrary(path)
+	if err != nil {
+		return nil, fmt.Errorf("load tweet pattern library: %w", err)
+	}
+
+	return l, nil
+}
+
+func getTelegramBotToken(v *viper.Viper) (string, error) {
+	botToken := v.GetString("TELEGRAM_BOT_TOKEN")
+	if botToken == "" {
+		return "", errEmptyTelegramBotToken
+	}
+
+	return botToken, nil
+}
+
+// getTelegramPatternLibrary loads TELEGRAM_PATTERN_FILE, the channels to listen on are given by its patterns.
+func getTelegramPatternLibrary(v *viper.Viper) (*tweet.PatternLibrary, error) {
+	path := v.GetString("TELEGRAM_PATTERN_FILE")
+	if path == "" {
+		return nil, errEmptyTelegramPatternFile
+	}
+
+	l, err := tweet.LoadPatternLibrary(path)
+	if err != nil {
+		return nil, fmt.Errorf("load telegram pattern library: %w", err)
+	}
+
+	return l, nil
+}
+
+// getFeeds loads FEED_FILE, the RSS and Atom feeds to poll and the patterns of each.
+func getFeeds(v *viper.Viper) ([]feed.Feed, error) {
+	path := v.GetString("FEED_FILE")
+	if path == "" {
+		return nil, errEmptyFeedFile
+	}
+
+	feeds, err := feed.LoadFeeds(path)
 	if err != nil {
-		return nil, fmt.Errorf("coingecko get coins list: %w", err)
+		return nil, fmt.Errorf("load feeds: %w", err)
+	}
+
+	return feeds, nil
+}
+
+func getFeedPollInterval(v *viper.Viper) time.Duration {
+	if d := v.GetDuration("FEED_POLL_INTERVAL"); d > 0 {
+		return d
+	}
+
+	return defaultFeedPollInterval
+}
+
+func getJournalFile(v *viper.Viper) string {
+	return v.GetString("JOURNAL_FILE")
+}
+
+func hasPositionDeadlineFile(v *viper.Viper) bool {
+	return v.GetString("POSITION_DEADLINE_FILE") != ""
+}
+
+func isPaperTrading(v *viper.Viper) bool {
+	return v.GetBool("PAPER_TRADING")
+}
+
+func getPaperOptions(v *viper.Viper) []trading.PaperOption {
+	var opts []trading.PaperOption
+
+	initialBalanceInUSD := v.GetFloat64("PAPER_INITIAL_BALANCE_IN_USD")
+	if initialBalanceInUSD > 0 {
+		opts = append(opts, trading.WithPaperInitialBalanceInUSD(initialBalanceInUSD))
+	}
+
+	slippagePercentage := v.GetFloat64("PAPER_SLIPPAGE_PERCENTAGE")
+	if slippagePercentage > 0 {
+		opts = append(opts, trading.WithPaperSlippagePercentage(slippagePercentage))
+	}
+
+	return opts
+}
+
+func getRiskOptions(v *viper.Viper) []trading.RiskOption {
+	var opts []trading.RiskOption
+
+	maxConcurrentPositions := v.GetInt("RISK_MAX_CONCURRENT_POSITIONS")
+	if maxConcurrentPositions > 0 {
+		opts = append(opts, trading.WithMaxConcurrentPositions(maxConcurrentPositions))
+	}
+
+	maxTotalNotionalInUSD := v.GetFloat64("RISK_MAX_TOTAL_NOTIONAL_IN_USD")
+	if maxTotalNotionalInUSD > 0 {
+		opts = append(opts, trading.WithMaxTotalNotionalInUSD(maxTotalNotionalInUSD))
+	}
+
+	dailyLossLimitInUSD := v.GetFloat64("RISK_DAILY_LOSS_LIMIT_IN_USD")
+	if dailyLossLimitInUSD > 0 {
+		opts = append(opts, trading.WithDailyLossLimitInUSD(dailyLossLimitInUSD))
+	}
+
+	entryCooldown := v.GetDuration("RISK_ENTRY_COOLDOWN")
+	if entryCooldown > 0 {
+		opts = append(opts, trading.WithEntryCooldown(entryCooldown))
+	}
+
+	if killSwitchFile := v.GetString("KILL_SWITCH_FILE"); killSwitchFile != "" {
+		opts = append(opts, trading.WithKillSwitchFile(killSwitchFile))
+	}
+
+	return opts
+}
+
+func getCoinRegistryOptions(v *viper.Viper, logger *zap.Logger) []coin.Option {
+	opts := []coin.Option{
+		coin.WithLogger(logger),
+		coin.WithHTTPClient(&http.Client{
+			Timeout: longHTTPTimeout,
+		}),
+	}
+
+	if cacheFile := v.GetString("COIN_CACHE_FILE"); cacheFile != "" {
+		opts = append(opts, coin.WithCacheFile(cacheFile))
+	}
+
+	if allowlist := splitList(v.GetString("COIN_ALLOWLIST")); len(allowlist) > 0 {
+		opts = append(opts, coin.WithAllowlist(allowlist...))
+	}
+
+	if denylist := splitList(v.GetString("COIN_DENYLIST")); len(denylist) > 0 {
+		opts = append(opts, coin.WithDenylist(denylist...))
 	}
 
-	symbolSet := make(map[string]struct{}, len(*coins))
-	for _, c := range *coins {
-		symbolSet[strings.ToUpper(c.Symbol)] = struct{}{}
+	return opts
+}
+
+func getCoinRefreshInterval(v *viper.Viper) time.Duration {
+	if d := v.GetDuration("COIN_REFRESH_INTERVAL"); d > 0 {
+		return d
+	}
+
+	return defaultCoinRefreshInterval
+}
+
+// splitList splits a comma separated list, without the empty items.
+func splitList(s string) []string {
+	res := []string{}
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
 	}
 
-	return symbolSet, nil
+	return res
 }
@@ -11,9 +11,10 @@ import (
 	"github.com/adshao/go-binance/v2"
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/blendle/zapdriver"
-	"github.com/dghubble/go-twitter/twitter"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/lht102/ctrade/pkg/dedup"
+	"github.com/lht102/ctrade/pkg/journal"
 	"github.com/lht102/ctrade/pkg/trading"
-	"github.com/lht102/ctrade/pkg/tweet"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
@@ -35,11 +36,16 @@ func main() {
 		_ = logger.Sync()
 	}()
 
-	supportedCoins, err := getSupportedCoins()
-	if err != nil {
-		logger.Fatal("Fail to get supported coins", zap.Error(err))
+	coinRegistry := coin.NewRegistry(getCoinRegistryOptions(v, logger)...)
+	if err := coinRegistry.Load(context.Background()); err != nil {
+		logger.Error("Fail to load coin list, every coin is rejected until it is fetched again", zap.Error(err))
 	}
 
+	coinCtx, cancelCoin := context.WithCancel(context.Background())
+	defer cancelCoin()
+
+	go coinRegistry.Run(coinCtx, getCoinRefreshInterval(v))
+
 	binanceAPIKey, err := getBinanceAPIKey(v)
 	if err != nil {
 		logger.Fatal("Fail to get binance API key", zap.Error(err))
@@ -53,15 +59,80 @@ func main() {
 	binanceFuturesClient := binance.NewFuturesClient(binanceAPIKey, binanceAPISecretKey)
 	binanceFuturesClient.HTTPClient.Timeout = shortHTTPTimeout
 
+	var exchange trading.Exchange = trading.NewBinanceFuturesExchange(binanceFuturesClient)
+
+	futuresOpts, err := getFuturesOptions(v)
+	if err != nil {
+		logger.Fatal("Fail to get futures options", zap.Error(err))
+	}
+
+	var signalJournal *journal.Journal
+
+	if journalFile := getJournalFile(v); journalFile != "" {
+		signalJournal, err = journal.Open(journalFile)
+		if err != nil {
+			logger.Fatal("Fail to open journal", zap.Error(err))
+		}
+
+		defer func() {
+			if err := signalJournal.Close(); err != nil {
+				logger.Error("Fail to close journal", zap.Error(err))
+			}
+		}()
+
+		futuresOpts = append(futuresOpts, trading.WithJournal(signalJournal))
+		if !hasPositionDeadlineFile(v) {
+			futuresOpts = append(futuresOpts, trading.WithDeadlineStore(signalJournal))
+		}
+	}
+
+	if isPaperTrading(v) {
+		paperExchange := trading.NewPaperExchange(exchange, logger, getPaperOptions(v)...)
+		exchange = paperExchange
+		futuresOpts = append(futuresOpts, trading.WithWillExecuteOrder(true))
+
+		paperCtx, cancelPaper := context.WithCancel(context.Background())
+		defer cancelPaper()
+
+		go paperExchange.Run(paperCtx, paperPriceFeedInterval)
+
+		defer func() {
+			pnl := paperExchange.PnL()
+			logger.Info(
+				"Paper trading result",
+				zap.String("balance", pnl.Balance.String()),
+				zap.String("realizedPnL", pnl.RealizedPnL.String()),
+				zap.String("unrealizedPnL", pnl.UnrealizedPnL.String()),
+			)
+		}()
+	}
+
 	binanceFuturesManager, err := trading.NewBinanceFuturesManager(
-		binanceFuturesClient,
+		exchange,
 		logger,
-		getFuturesOptions(v)...,
+		futuresOpts...,
 	)
 	if err != nil {
 		logger.Fatal("Fail to init binance futures manager", zap.Error(err))
 	}
 
+	riskManager := trading.NewRiskManager(binanceFuturesManager, logger, getRiskOptions(v)...)
+
+	reconcileReport, err := binanceFuturesManager.Reconcile()
+	if err != nil {
+		logger.Fatal("Fail to reconcile positions", zap.Error(err))
+	}
+
+	logger.Info(
+		"Reconciled positions",
+		zap.Strings("resumed", reconcileReport.Resumed),
+		zap.Strings("protected", reconcileReport.Protected),
+		zap.Strings("orphaned", reconcileReport.Orphaned),
+		zap.Strings("failed", reconcileReport.Failed),
+		zap.Strings("unprotected", reconcileReport.Unprotected),
+		zap.Strings("unverified", reconcileReport.Unverified),
+	)
+
 	ticker := time.NewTicker(updateBinanceExchangeInfoInterval)
 	defer ticker.Stop()
 
@@ -73,37 +144,94 @@ func main() {
 		}
 	}()
 
-	twitterAuthCfg, err := getTwitterClientCredentialsConfig(v)
+	exitOrdersTicker := time.NewTicker(checkExitOrdersInterval)
+	defer exitOrdersTicker.Stop()
+
+	go func() {
+		for range exitOrdersTicker.C {
+			if err := binanceFuturesManager.CheckExitOrders(); err != nil {
+				logger.Error("Fail to check exit orders", zap.Error(err))
+			}
+		}
+	}()
+
+	deadlinesTicker := time.NewTicker(checkPositionDeadlinesInterval)
+	defer deadlinesTicker.Stop()
+
+	go func() {
+		for range deadlinesTicker.C {
+			if err := binanceFuturesManager.CheckPositionDeadlines(); err != nil {
+				logger.Error("Fail to check position deadlines", zap.Error(err))
+			}
+		}
+	}()
+
+	sourceRegistry, err := newSignalSourceRegistry(v, logger, coinRegistry)
 	if err != nil {
-		logger.Fatal("Fail to init twitter client credentials config", zap.Error(err))
+		logger.Fatal("Fail to init signal sources", zap.Error(err))
 	}
 
-	twitterAccessTokenCfg, err := getTwitterAccessTokenConfig(v)
+	signalChFromSources, err := sourceRegistry.Start(getSignalSources(v)...)
 	if err != nil {
-		logger.Fatal("Fail to init twitter access token config", zap.Error(err))
+		logger.Fatal("Fail to start signal sources", zap.Error(err))
 	}
 
-	httpClient := twitterAuthCfg.Client(context.Background(), twitterAccessTokenCfg)
-	httpClient.Timeout = longHTTPTimeout
-	twitterClient := twitter.NewClient(httpClient)
-	tweetManager := tweet.NewManager(twitterClient, []string{tweet.CoinbaseProTwitterUserID}, supportedCoins)
+	defer sourceRegistry.Stop()
 
-	buySignalChFromTweet, err := tweetManager.SubscribeBuySignalChannel()
-	if err != nil {
-		logger.Fatal("Fail to subscribe buy signal", zap.Error(err))
+	var dedupOpts []dedup.Option
+	if signalJournal != nil {
+		dedupOpts = append(dedupOpts, dedup.WithStore(signalJournal))
 	}
 
-	defer tweetManager.Stop()
+	deduplicator := dedup.NewDeduplicator(getSignalDedupWindow(v), logger, dedupOpts...)
 
 	go func() {
-		logger.Info("Start listening on buy signal channel from tweets")
+		logger.Info("Start listening on signal channel")
+
+		for v := range signalChFromSources {
+			buySignal := v.BuySignal()
+
+			if deduplicator.IsDuplicate(buySignal) {
+				logger.Info("Drop duplicated signal", zap.String("symbol", v.Symbol), zap.String("source", v.Source))
 
-		for v := range buySignalChFromTweet {
-			logger.Info("Incoming buy signal", zap.String("symbol", v.Symbol), zap.String("source", v.Source))
+				continue
+			}
 
-			if err := binanceFuturesManager.ConsumeBuySignal(v); err != nil {
-				logger.Error("Fail to consume buy signal", zap.Error(err))
+			logger.Info(
+				"Incoming signal",
+				zap.String("symbol", v.Symbol),
+				zap.String("source", v.Source),
+				zap.String("sourceKind", string(v.SourceKind)),
+				zap.String("stage", string(v.Stage)),
+				zap.String("side", string(v.Side)),
+				zap.Float64("confidence", v.Confidence),
+				zap.Duration("latency", v.Latency()),
+			)
+
+			if signalJournal != nil {
+				if _, err := signalJournal.RecordSignal(journal.SignalRecord{
+					Symbol:     v.Symbol,
+					Source:     v.Source,
+					Stage:      string(v.Stage),
+					Side:       string(v.Side),
+					DedupKey:   dedup.Key(buySignal),
+					ReceivedAt: time.Now(),
+					SourceKind: string(v.SourceKind),
+					DetectedAt: v.DetectedAt,
+					EventAt:    v.EventAt,
+				}); err != nil {
+					logger.Error("Fail to record buy signal", zap.Error(err))
+				}
 			}
+
+			if err := riskManager.ConsumeSignal(buySignal); err != nil {
+				logger.Error("Fail to consume signal", zap.Error(err))
+
+				continue
+			}
+
+			// only a consumed signal is marked, so that the same signal from another source is retried
+			deduplicator.MarkSeen(buySignal)
 		}
 	}()
 
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/lht102/ctrade/pkg/announcement"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/lht102/ctrade/pkg/feed"
+	"github.com/lht102/ctrade/pkg/source"
+	"github.com/lht102/ctrade/pkg/telegram"
+	"github.com/lht102/ctrade/pkg/tweet"
+	"github.com/lht102/ctrade/pkg/webhook"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+var (
+	_ source.SignalSource = (*tweet.Manager)(nil)
+	_ source.SignalSource = (*announcement.BinanceListingSource)(nil)
+	_ source.SignalSource = (*webhook.Server)(nil)
+	_ source.SignalSource = (*telegram.Source)(nil)
+	_ source.SignalSource = (*feed.Source)(nil)
+)
+
+// newSignalSourceRegistry registers every signal source the application knows about,
+// the enabled ones are picked by SIGNAL_SOURCES.
+func newSignalSourceRegistry(v *viper.Viper, logger *zap.Logger, supportedCoins coin.Set) (*source.Registry, error) {
+	r := source.NewRegistry(logger)
+
+	if err := r.Register(tweet.SourceName, func() (source.SignalSource, error) {
+		return newTweetManager(v, logger, supportedCoins)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := r.Register(announcement.BinanceSourceName, func() (source.SignalSource, error) {
+		return announcement.NewBinanceListingSource(
+			announcement.WithLogger(logger),
+			announcement.WithPollInterval(getBinanceAnnouncementPollInterval(v)),
+		), nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := r.Register(webhook.SourceName, func() (source.SignalSource, error) {
+		return newWebhookServer(v, logger)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := r.Register(telegram.SourceName, func() (source.SignalSource, error) {
+		return newTelegramSource(v, logger, supportedCoins)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := r.Register(feed.SourceName, func() (source.SignalSource, error) {
+		feeds, err := getFeeds(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return feed.NewSource(
+			feeds,
+			supportedCoins,
+			feed.WithLogger(logger),
+			feed.WithPollInterval(getFeedPollInterval(v)),
+		), nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func newTelegramSource(v *viper.Viper, logger *zap.Logger, supportedCoins coin.Set) (*telegram.Source, error) {
+	botToken, err := getTelegramBotToken(v)
+	if err != nil {
+		return nil, err
+	}
+
+	patternLibrary, err := getTelegramPatternLibrary(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return telegram.NewSource(
+		botToken,
+		patternLibrary,
+		supportedCoins,
+		telegram.WithLogger(logger),
+		// the long poll holds the request for up to the poll timeout
+		telegram.WithPollTimeout(longHTTPTimeout),
+		telegram.WithHTTPClient(&http.Client{
+			Timeout: longHTTPTimeout + shortHTTPTimeout,
+		}),
+	), nil
+}
+
+func newWebhookServer(v *viper.Viper, logger *zap.Logger) (*webhook.Server, error) {
+	secret, err := getWebhookSecret(v)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []webhook.Option{webhook.WithLogger(logger)}
+	if replayWindow := v.GetDuration("WEBHOOK_REPLAY_WINDOW"); replayWindow > 0 {
+		opts = append(opts, webhook.WithReplayWindow(replayWindow))
+	}
+
+	return webhook.NewServer(getWebhookAddr(v), secret, opts...), nil
+}
+
+func newTweetManager(v *viper.Viper, logger *zap.Logger, supportedCoins coin.Set) (*tweet.Manager, error) {
+	tweetManagerOpts := []tweet.ManagerOption{
+		tweet.WithLogger(logger),
+		tweet.WithStateChangeHandler(func(s tweet.StreamState) {
+			logger.Info("Twitter stream state changed", zap.Stringer("state", s))
+		}),
+	}
+
+	patternLibrary, err := getTweetPatternLibrary(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// the accounts of the patterns are followed, otherwise their tweets would never be streamed
+	twitterUserIDs := []string{tweet.CoinbaseProTwitterUserID}
+
+	if patternLibrary != nil {
+		tweetManagerOpts = append(tweetManagerOpts, tweet.WithPatternLibrary(patternLibrary))
+
+		if accounts := patternLibrary.Accounts(); len(accounts) > 0 {
+			twitterUserIDs = accounts
+		}
+	}
+
+	var twitterClient *twitter.Client
+
+	if bearerToken := getTwitterBearerToken(v); bearerToken != "" {
+		tweetManagerOpts = append(tweetManagerOpts, tweet.WithStreamV2Client(tweet.NewStreamV2Client(bearerToken)))
+	} else {
+		twitterAuthCfg, err := getTwitterClientCredentialsConfig(v)
+		if err != nil {
+			return nil, err
+		}
+
+		twitterAccessTokenCfg, err := getTwitterAccessTokenConfig(v)
+		if err != nil {
+			return nil, err
+		}
+
+		httpClient := twitterAuthCfg.Client(context.Background(), twitterAccessTokenCfg)
+		httpClient.Timeout = longHTTPTimeout
+		twitterClient = twitter.NewClient(httpClient)
+	}
+
+	return tweet.NewManager(
+		twitterClient,
+		twitterUserIDs,
+		supportedCoins,
+		tweetManagerOpts...,
+	), nil
+}
@@ -11,6 +11,7 @@ require (
 	github.com/shopspring/decimal v1.2.0
 	github.com/spf13/viper v1.8.1
 	github.com/stretchr/testify v1.7.0
-	github.com/superoo7/go-gecko v1.0.0
+	go.etcd.io/bbolt v1.3.6
 	go.uber.org/zap v1.17.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,306 @@
+package announcement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"go.uber.org/zap"
+)
+
+const (
+	BinanceSourceName = "binance_announcement"
+
+	binanceArticleListPath     = "/bapi/composite/v1/public/cms/article/list/query"
+	binanceNewListingCatalogID = 48
+	binanceDelistingCatalogID  = 161
+	binanceSuccessCode         = "000000"
+)
+
+var (
+	errUnexpectedStatusCode = errors.New("unexpected status code")
+	errUnexpectedResponse   = errors.New("unexpected response")
+)
+
+var (
+	// "Binance Will List Arbitrum (ARB) and Sui (SUI)"
+	willListPattern = regexp.MustCompile(`(?i)\bwill list\b`) // nolint: gochecknoglobals
+	// symbols in brackets, e.g. "(ARB)"
+	bracketSymbolPattern = regexp.MustCompile(`\(([A-Z0-9]+)\)`) // nolint: gochecknoglobals
+	// "Binance Futures Will Launch USDⓈ-M ARB and 1000PEPE Perpetual Contracts"
+	futuresLaunchPattern = regexp.MustCompile(`(?i)\bfutures will launch\b.*?\bUSD\S*-M\s+(.+?)\s+perpetual`) // nolint: gochecknoglobals
+	// "Binance Will Delist BTCST, DREP, MIR & TORN on 2023-03-31"
+	delistPattern = regexp.MustCompile(`(?i)\bwill delist\s+(.+?)(?:\s+on\b|$)`) // nolint: gochecknoglobals
+	// "Binance Will Suspend Trading on LUNA/BUSD and UST/BUSD", suspensions of deposits and withdrawals
+	// are left out as they are mostly network upgrades
+	suspendTradingPattern = regexp.MustCompile(`(?i)\bsuspend(?:s|ed)?\s+(?:spot\s+)?trading\b`) // nolint: gochecknoglobals
+	// base assets of trading pairs, e.g. "LUNA/BUSD"
+	pairBaseSymbolPattern = regexp.MustCompile(`\b([A-Z0-9]+)/[A-Z]+\b`) // nolint: gochecknoglobals
+	symbolPattern         = regexp.MustCompile(`^[A-Z0-9]+$`)            // nolint: gochecknoglobals
+)
+
+// binanceCatalogIDs are the announcement catalogs polled, new listings and delistings.
+var binanceCatalogIDs = []int{binanceNewListingCatalogID, binanceDelistingCatalogID} // nolint: gochecknoglobals
+
+type binanceArticle struct {
+	ID          int64  `json:"id"`
+	Code        string `json:"code"`
+	Title       string `json:"title"`
+	ReleaseDate int64  `json:"releaseDate"`
+}
+
+type binanceArticleListResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Catalogs []struct {
+			CatalogID int              `json:"catalogId"`
+			Articles  []binanceArticle `json:"articles"`
+		} `json:"catalogs"`
+	} `json:"data"`
+}
+
+// BinanceListingSource polls the Binance new cryptocurrency listing and delisting announcements and emits
+// a buy signal for every symbol of a new "Will List" or "Futures Will Launch" announcement, and a sell signal
+// for every symbol of a new "Will Delist" or "Suspend Trading" announcement.
+type BinanceListingSource struct {
+	opts options
+
+	seen map[int64]struct{}
+	// primed once the announcements published before Start are marked as seen
+	primed   bool
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewBinanceListingSource(opts ...Option) *BinanceListingSource {
+	options := newDefaultOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &BinanceListingSource{
+		opts: options,
+		seen: make(map[int64]struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+func (s *BinanceListingSource) Name() string {
+	return BinanceSourceName
+}
+
+// Start marks the announcements already published as seen, so that only the ones published afterwards
+// produce signals, and then polls in the background until Stop. If they cannot be fetched at start, they
+// are marked on the first successful poll instead.
+func (s *BinanceListingSource) Start() (<-chan api.Signal, error) {
+	if _, err := s.poll(context.Background()); err != nil {
+		s.opts.logger.Error("Fail to poll binance announcements, they are marked as seen on the next poll", zap.Error(err))
+	}
+
+	signalCh := make(chan api.Signal)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer close(signalCh)
+
+		ticker := time.NewTicker(s.opts.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+
+			signals, err := s.poll(context.Background())
+			if err != nil {
+				s.opts.logger.Error("Fail to poll binance announcements", zap.Error(err))
+
+				continue
+			}
+
+			for _, v := range signals {
+				select {
+				case <-s.done:
+					return
+				case signalCh <- v:
+				}
+			}
+		}
+	}()
+
+	return signalCh, nil
+}
+
+func (s *BinanceListingSource) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+
+	s.wg.Wait()
+}
+
+// poll returns the signals of the announcements not seen before, oldest first, none until it is primed.
+func (s *BinanceListingSource) poll(ctx context.Context) ([]api.Signal, error) {
+	articles := []binanceArticle{}
+
+	for _, catalogID := range binanceCatalogIDs {
+		catalogArticles, err := s.listArticles(ctx, catalogID)
+		if err != nil {
+			return nil, err
+		}
+
+		articles = append(articles, catalogArticles...)
+	}
+
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].ReleaseDate < articles[j].ReleaseDate
+	})
+
+	res := []api.Signal{}
+	detectedAt := time.Now()
+
+	for _, a := range articles {
+		if _, ok := s.seen[a.ID]; ok {
+			continue
+		}
+
+		s.seen[a.ID] = struct{}{}
+
+		if !s.primed {
+			continue
+		}
+
+		symbols, side := parseAnnouncementTitle(a.Title)
+		for _, symbol := range symbols {
+			s.opts.logger.Info(
+				"Detected binance announcement",
+				zap.String("symbol", symbol),
+				zap.String("side", string(side)),
+				zap.String("title", a.Title),
+			)
+
+			signal := api.NewSignal(api.BuySignal{
+				Symbol: symbol,
+				Source: s.opts.baseURL + "/en/support/announcement/" + a.Code,
+				Side:   side,
+			}, api.SourceKindAnnouncement, detectedAt)
+			signal.EventAt = time.Unix(0, a.ReleaseDate*int64(time.Millisecond))
+			signal.RawText = a.Title
+
+			res = append(res, signal)
+		}
+	}
+
+	s.primed = true
+
+	return res, nil
+}
+
+func (s *BinanceListingSource) listArticles(ctx context.Context, catalogID int) ([]binanceArticle, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.opts.baseURL+binanceArticleListPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("type", "1")
+	q.Set("catalogId", strconv.Itoa(catalogID))
+	q.Set("pageNo", "1")
+	q.Set("pageSize", strconv.Itoa(s.opts.pageSize))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := s.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list binance announcements: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list binance announcements: %w %d", errUnexpectedStatusCode, resp.StatusCode)
+	}
+
+	var body binanceArticleListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode binance announcements: %w", err)
+	}
+
+	if body.Code != binanceSuccessCode {
+		return nil, fmt.Errorf("list binance announcements: %w: %s %s", errUnexpectedResponse, body.Code, body.Message)
+	}
+
+	res := []binanceArticle{}
+
+	for _, c := range body.Data.Catalogs {
+		if c.CatalogID == catalogID {
+			res = append(res, c.Articles...)
+		}
+	}
+
+	return res, nil
+}
+
+// parseAnnouncementTitle returns the symbols of a listing, delisting or trading suspension announcement title
+// and the side of the signal, nil symbols if it is none of them.
+func parseAnnouncementTitle(title string) ([]string, api.SignalSide) {
+	if m := delistPattern.FindStringSubmatch(title); m != nil {
+		return splitSymbols(m[1]), api.SignalSideSell
+	}
+
+	if suspendTradingPattern.MatchString(title) {
+		res := []string{}
+		seen := map[string]struct{}{}
+
+		for _, m := range append(bracketSymbolPattern.FindAllStringSubmatch(title, -1), pairBaseSymbolPattern.FindAllStringSubmatch(title, -1)...) {
+			if _, ok := seen[m[1]]; !ok {
+				seen[m[1]] = struct{}{}
+				res = append(res, m[1])
+			}
+		}
+
+		return res, api.SignalSideSell
+	}
+
+	if m := futuresLaunchPattern.FindStringSubmatch(title); m != nil {
+		return splitSymbols(m[1]), api.SignalSideBuy
+	}
+
+	if willListPattern.MatchString(title) {
+		res := []string{}
+
+		for _, m := range bracketSymbolPattern.FindAllStringSubmatch(title, -1) {
+			res = append(res, m[1])
+		}
+
+		return res, api.SignalSideBuy
+	}
+
+	return nil, ""
+}
+
+// splitSymbols returns the symbols in a list like "ID, ARB & RDNT" or "1000PEPE and SUI".
+func splitSymbols(s string) []string {
+	res := []string{}
+
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '&' || r == ' ' }) {
+		if symbolPattern.MatchString(w) {
+			res = append(res, w)
+		}
+	}
+
+	return res
+}
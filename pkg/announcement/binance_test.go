@@ -0,0 +1,163 @@
+package announcement
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureServer serves the saved responses of each catalog in order, the last one is repeated.
+type fixtureServer struct {
+	t        *testing.T
+	mu       sync.Mutex
+	fixtures map[string][]string
+}
+
+func (s *fixtureServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	catalogID := r.URL.Query().Get("catalogId")
+
+	s.mu.Lock()
+	fixtures, ok := s.fixtures[catalogID]
+	if ok && len(fixtures) > 1 {
+		s.fixtures[catalogID] = fixtures[1:]
+	}
+	s.mu.Unlock()
+
+	if r.URL.Path != binanceArticleListPath || !ok {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	b, err := os.ReadFile(filepath.Join("testdata", fixtures[0]))
+	require.NoError(s.t, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func TestParseAnnouncementTitle(t *testing.T) {
+	testCases := []struct {
+		in   string
+		out  []string
+		side api.SignalSide
+	}{
+		{in: "Binance Will List Arbitrum (ARB)", out: []string{"ARB"}, side: api.SignalSideBuy},
+		{in: "Binance Will List Sui (SUI) and Pepe (PEPE) with Seed Tag Applied", out: []string{"SUI", "PEPE"}, side: api.SignalSideBuy},
+		{in: "Binance Futures Will Launch USDⓈ-M ARB Perpetual Contract With Up to 20x Leverage", out: []string{"ARB"}, side: api.SignalSideBuy},
+		{in: "Binance Futures Will Launch USDⓈ-M 1000PEPE and SUI Perpetual Contracts With Up to 25x Leverage", out: []string{"1000PEPE", "SUI"}, side: api.SignalSideBuy},
+		{in: "Binance Futures Will Launch USDⓈ-M ID, ARB & RDNT Perpetual Contracts", out: []string{"ID", "ARB", "RDNT"}, side: api.SignalSideBuy},
+		{in: "Binance Will Delist BTCST, DREP, MIR & TORN", out: []string{"BTCST", "DREP", "MIR", "TORN"}, side: api.SignalSideSell},
+		{in: "Binance Will Delist ANC, VGX & WNXM on 2023-02-17", out: []string{"ANC", "VGX", "WNXM"}, side: api.SignalSideSell},
+		{in: "Binance Will Suspend Trading on LUNA/BUSD, LUNA/USDT and UST/BUSD", out: []string{"LUNA", "UST"}, side: api.SignalSideSell},
+		{in: "Binance Will Suspend Trading of Terra (LUNA)", out: []string{"LUNA"}, side: api.SignalSideSell},
+		{in: "Binance Will Suspend Deposits and Withdrawals on the Solana Network (SOL)", out: nil, side: ""},
+		{in: "Binance Adds ARB/TUSD, OP/TUSD Trading Pairs", out: nil, side: ""},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			symbols, side := parseAnnouncementTitle(tc.in)
+			assert.Equal(t, tc.out, symbols)
+			assert.Equal(t, tc.side, side)
+		})
+	}
+}
+
+func TestBinanceListingSource(t *testing.T) {
+	server := httptest.NewServer(&fixtureServer{
+		t: t,
+		fixtures: map[string][]string{
+			"48":  {"binance_listing_initial.json", "binance_error.json", "binance_listing_updated.json"},
+			"161": {"binance_delisting_initial.json", "binance_delisting_updated.json"},
+		},
+	})
+	defer server.Close()
+
+	s := NewBinanceListingSource(WithBaseURL(server.URL), WithPollInterval(10*time.Millisecond))
+
+	ch, err := s.Start()
+	require.NoError(t, err)
+
+	signals := []api.BuySignal{}
+
+	for len(signals) < 9 {
+		select {
+		case v := <-ch:
+			assert.Equal(t, api.SourceKindAnnouncement, v.SourceKind)
+			assert.False(t, v.EventAt.IsZero())
+			signals = append(signals, v.BuySignal())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for signals")
+		}
+	}
+
+	assert.Equal(t, []api.BuySignal{
+		{Symbol: "SUI", Source: server.URL + "/en/support/announcement/8a7d6c5b4e3f2a1b0c9d8e7f6a5b4c3d", Side: api.SignalSideBuy},
+		{Symbol: "PEPE", Source: server.URL + "/en/support/announcement/8a7d6c5b4e3f2a1b0c9d8e7f6a5b4c3d", Side: api.SignalSideBuy},
+		{Symbol: "1000PEPE", Source: server.URL + "/en/support/announcement/0c2e1a6d4b5e4f3f8e6b1d2c3a4f5e60", Side: api.SignalSideBuy},
+		{Symbol: "SUI", Source: server.URL + "/en/support/announcement/0c2e1a6d4b5e4f3f8e6b1d2c3a4f5e60", Side: api.SignalSideBuy},
+		{Symbol: "ANC", Source: server.URL + "/en/support/announcement/3e4d5c6b7a8f9e0d1c2b3a4f5e6d7c8b", Side: api.SignalSideSell},
+		{Symbol: "VGX", Source: server.URL + "/en/support/announcement/3e4d5c6b7a8f9e0d1c2b3a4f5e6d7c8b", Side: api.SignalSideSell},
+		{Symbol: "WNXM", Source: server.URL + "/en/support/announcement/3e4d5c6b7a8f9e0d1c2b3a4f5e6d7c8b", Side: api.SignalSideSell},
+		{Symbol: "LUNA", Source: server.URL + "/en/support/announcement/9c8b7a6f5e4d3c2b1a0f9e8d7c6b5a4f", Side: api.SignalSideSell},
+		{Symbol: "UST", Source: server.URL + "/en/support/announcement/9c8b7a6f5e4d3c2b1a0f9e8d7c6b5a4f", Side: api.SignalSideSell},
+	}, signals)
+
+	select {
+	case v := <-ch:
+		t.Fatalf("unexpected signal %v", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.Stop()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestBinanceListingSourceStartError(t *testing.T) {
+	server := httptest.NewServer(&fixtureServer{
+		t: t,
+		fixtures: map[string][]string{
+			"48":  {"binance_error.json", "binance_listing_initial.json", "binance_listing_updated.json"},
+			"161": {"binance_delisting_initial.json"},
+		},
+	})
+	defer server.Close()
+
+	s := NewBinanceListingSource(WithBaseURL(server.URL), WithPollInterval(10*time.Millisecond))
+
+	ch, err := s.Start()
+	require.NoError(t, err)
+
+	symbols := []string{}
+
+	for len(symbols) < 4 {
+		select {
+		case v := <-ch:
+			symbols = append(symbols, v.Symbol)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for signals")
+		}
+	}
+
+	assert.Equal(t, []string{"SUI", "PEPE", "1000PEPE", "SUI"}, symbols, "the announcements of the first successful poll are marked as seen")
+
+	s.Stop()
+	s.Stop()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
@@ -0,0 +1,92 @@
+package announcement
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBinanceBaseURL = "https://www.binance.com"
+	defaultPollInterval   = 30 * time.Second
+	defaultHTTPTimeout    = 10 * time.Second
+	defaultPageSize       = 20
+)
+
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	baseURL      string
+	httpClient   *http.Client
+	pollInterval time.Duration
+	pageSize     int
+	logger       *zap.Logger
+}
+
+func newDefaultOptions() options {
+	return options{
+		baseURL:      defaultBinanceBaseURL,
+		httpClient:   &http.Client{Timeout: defaultHTTPTimeout},
+		pollInterval: defaultPollInterval,
+		pageSize:     defaultPageSize,
+		logger:       zap.NewNop(),
+	}
+}
+
+type baseURLOption string
+
+func (c baseURLOption) apply(opts *options) {
+	opts.baseURL = string(c)
+}
+
+func WithBaseURL(baseURL string) Option {
+	return baseURLOption(baseURL)
+}
+
+type httpClientOption struct {
+	httpClient *http.Client
+}
+
+func (c httpClientOption) apply(opts *options) {
+	opts.httpClient = c.httpClient
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return httpClientOption{httpClient: httpClient}
+}
+
+type pollIntervalOption time.Duration
+
+func (c pollIntervalOption) apply(opts *options) {
+	opts.pollInterval = time.Duration(c)
+}
+
+func WithPollInterval(d time.Duration) Option {
+	return pollIntervalOption(d)
+}
+
+type pageSizeOption int
+
+func (c pageSizeOption) apply(opts *options) {
+	opts.pageSize = int(c)
+}
+
+// WithPageSize sets how many of the latest announcements are fetched in each poll.
+func WithPageSize(n int) Option {
+	return pageSizeOption(n)
+}
+
+type loggerOption struct {
+	logger *zap.Logger
+}
+
+func (c loggerOption) apply(opts *options) {
+	opts.logger = c.logger
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return loggerOption{logger: logger}
+}
@@ -0,0 +1,15 @@
+package coin
+
+// Set tells whether a symbol is a coin that signals can be traded on.
+type Set interface {
+	Contains(symbol string) bool
+}
+
+// Symbols is a fixed set of upper case symbols.
+type Symbols map[string]struct{}
+
+func (s Symbols) Contains(symbol string) bool {
+	_, ok := s[symbol]
+
+	return ok
+}
@@ -0,0 +1,131 @@
+package coin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCoinGeckoBaseURL = "https://api.coingecko.com"
+	defaultHTTPTimeout      = 30 * time.Second
+	defaultRetryMinBackoff  = 5 * time.Second
+	defaultRetryMaxBackoff  = 5 * time.Minute
+)
+
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	baseURL    string
+	httpClient *http.Client
+	cacheFile  string
+	allowlist  map[string]struct{}
+	denylist   map[string]struct{}
+	logger     *zap.Logger
+
+	retryMinBackoff time.Duration
+	retryMaxBackoff time.Duration
+}
+
+func newDefaultOptions() options {
+	return options{
+		baseURL:    defaultCoinGeckoBaseURL,
+		httpClient: &http.Client{Timeout: defaultHTTPTimeout},
+		allowlist:  make(map[string]struct{}),
+		denylist:   make(map[string]struct{}),
+		logger:     zap.NewNop(),
+
+		retryMinBackoff: defaultRetryMinBackoff,
+		retryMaxBackoff: defaultRetryMaxBackoff,
+	}
+}
+
+type baseURLOption string
+
+func (c baseURLOption) apply(opts *options) {
+	opts.baseURL = string(c)
+}
+
+func WithBaseURL(baseURL string) Option {
+	return baseURLOption(baseURL)
+}
+
+type httpClientOption struct {
+	httpClient *http.Client
+}
+
+func (c httpClientOption) apply(opts *options) {
+	opts.httpClient = c.httpClient
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return httpClientOption{httpClient: httpClient}
+}
+
+type cacheFileOption string
+
+func (c cacheFileOption) apply(opts *options) {
+	opts.cacheFile = string(c)
+}
+
+// WithCacheFile saves every coin list fetched to the file, and loads the list from it when CoinGecko is unavailable.
+func WithCacheFile(path string) Option {
+	return cacheFileOption(path)
+}
+
+type allowlistOption []string
+
+func (c allowlistOption) apply(opts *options) {
+	for _, s := range c {
+		opts.allowlist[strings.ToUpper(s)] = struct{}{}
+	}
+}
+
+// WithAllowlist confirms the symbols, they are supported even if more than one coin has them.
+func WithAllowlist(symbols ...string) Option {
+	return allowlistOption(symbols)
+}
+
+type denylistOption []string
+
+func (c denylistOption) apply(opts *options) {
+	for _, s := range c {
+		opts.denylist[strings.ToUpper(s)] = struct{}{}
+	}
+}
+
+// WithDenylist rejects the symbols whatever the coin list says.
+func WithDenylist(symbols ...string) Option {
+	return denylistOption(symbols)
+}
+
+type retryBackoffOption struct {
+	min time.Duration
+	max time.Duration
+}
+
+func (c retryBackoffOption) apply(opts *options) {
+	opts.retryMinBackoff = c.min
+	opts.retryMaxBackoff = c.max
+}
+
+// WithRetryBackoff sets the range of the exponential backoff between fetches of a coin list never loaded.
+func WithRetryBackoff(min time.Duration, max time.Duration) Option {
+	return retryBackoffOption{min: min, max: max}
+}
+
+type loggerOption struct {
+	logger *zap.Logger
+}
+
+func (c loggerOption) apply(opts *options) {
+	opts.logger = c.logger
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return loggerOption{logger: logger}
+}
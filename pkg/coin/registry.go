@@ -0,0 +1,317 @@
+package coin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const coinGeckoCoinsListPath = "/api/v3/coins/list"
+
+var (
+	errUnexpectedStatusCode = errors.New("unexpected status code")
+	errEmptyCoinList        = errors.New("empty coin list")
+)
+
+// Coin is an entry of the CoinGecko coin list.
+type Coin struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+// Registry is the set of the CoinGecko coin symbols. A symbol shared by more than one coin is a collision,
+// it is only supported once confirmed by the allowlist. The denylisted symbols are never supported.
+type Registry struct {
+	opts options
+
+	mu    sync.RWMutex
+	coins map[string][]Coin
+}
+
+func NewRegistry(opts ...Option) *Registry {
+	options := newDefaultOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &Registry{
+		opts:  options,
+		coins: make(map[string][]Coin),
+	}
+}
+
+// Load fetches the coin list, or falls back to the cache file if CoinGecko is unavailable.
+func (r *Registry) Load(ctx context.Context) error {
+	err := r.Refresh(ctx)
+	if err == nil {
+		return nil
+	}
+
+	if r.opts.cacheFile == "" {
+		return err
+	}
+
+	coins, cacheErr := readCache(r.opts.cacheFile)
+	if cacheErr != nil {
+		return fmt.Errorf("fall back to cache after %s: %w", err.Error(), cacheErr)
+	}
+
+	r.opts.logger.Warn(
+		"Fail to fetch coin list, use the cached one",
+		zap.String("cacheFile", r.opts.cacheFile),
+		zap.Error(err),
+	)
+	r.set(coins)
+
+	return nil
+}
+
+// Refresh fetches the coin list and saves it to the cache file, the current list is kept on failure.
+func (r *Registry) Refresh(ctx context.Context) error {
+	coins, err := r.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.set(coins)
+
+	if r.opts.cacheFile != "" {
+		if err := writeCache(r.opts.cacheFile, coins); err != nil {
+			r.opts.logger.Error("Fail to write coin list cache", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// Run refreshes the coin list every interval until ctx is done. If the list has never been loaded, every
+// symbol is rejected, so it is fetched again with a backoff until it succeeds before the interval applies.
+func (r *Registry) Run(ctx context.Context, interval time.Duration) {
+	if r.size() == 0 && !r.retryUntilLoaded(ctx) {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.Refresh(ctx); err != nil {
+			r.opts.logger.Error("Fail to refresh coin list", zap.Error(err))
+		}
+	}
+}
+
+// retryUntilLoaded fetches the coin list with a backoff until it succeeds, false if ctx is done first.
+func (r *Registry) retryUntilLoaded(ctx context.Context) bool {
+	backoff := r.opts.retryMinBackoff
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+
+		err := r.Refresh(ctx)
+		if err == nil {
+			return true
+		}
+
+		if backoff *= 2; backoff > r.opts.retryMaxBackoff {
+			backoff = r.opts.retryMaxBackoff
+		}
+
+		r.opts.logger.Error("Fail to fetch coin list, every coin is rejected until it is loaded", zap.Duration("retryIn", backoff), zap.Error(err))
+	}
+}
+
+// Contains tells whether the symbol is supported.
+func (r *Registry) Contains(symbol string) bool {
+	symbol = strings.ToUpper(symbol)
+
+	if _, ok := r.opts.denylist[symbol]; ok {
+		return false
+	}
+
+	if _, ok := r.opts.allowlist[symbol]; ok {
+		return true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	coins := r.coins[symbol]
+	if len(coins) > 1 {
+		r.opts.logger.Warn("Reject ambiguous symbol", zap.String("symbol", symbol), zap.Strings("coins", coinIDs(coins)))
+
+		return false
+	}
+
+	return len(coins) == 1
+}
+
+// Collisions returns the coins of every symbol shared by more than one coin.
+func (r *Registry) Collisions() map[string][]Coin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make(map[string][]Coin)
+
+	for symbol, coins := range r.coins {
+		if len(coins) > 1 {
+			res[symbol] = append([]Coin{}, coins...)
+		}
+	}
+
+	return res
+}
+
+func (r *Registry) set(coins []Coin) {
+	bySymbol := make(map[string][]Coin, len(coins))
+	for _, c := range coins {
+		symbol := strings.ToUpper(c.Symbol)
+		bySymbol[symbol] = append(bySymbol[symbol], c)
+	}
+
+	r.mu.Lock()
+	r.coins = bySymbol
+	r.mu.Unlock()
+
+	r.reportCollisions()
+}
+
+// reportCollisions logs the colliding symbols that are neither confirmed nor denied, those are rejected.
+func (r *Registry) reportCollisions() {
+	unconfirmed := []string{}
+
+	for symbol := range r.Collisions() {
+		_, allowed := r.opts.allowlist[symbol]
+		_, denied := r.opts.denylist[symbol]
+
+		if !allowed && !denied {
+			unconfirmed = append(unconfirmed, symbol)
+		}
+	}
+
+	sort.Strings(unconfirmed)
+
+	r.opts.logger.Info(
+		"Loaded coin list",
+		zap.Int("symbols", r.size()),
+		zap.Int("unconfirmedCollisions", len(unconfirmed)),
+	)
+
+	if len(unconfirmed) == 0 {
+		return
+	}
+
+	collisions := r.Collisions()
+	details := make([]string, 0, len(unconfirmed))
+
+	for _, symbol := range unconfirmed {
+		details = append(details, symbol+": "+strings.Join(coinIDs(collisions[symbol]), ","))
+	}
+
+	r.opts.logger.Warn(
+		"Unconfirmed symbol collisions are rejected until they are allowlisted or denylisted",
+		zap.Strings("symbols", unconfirmed),
+		zap.Strings("coins", details),
+	)
+}
+
+func (r *Registry) size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.coins)
+}
+
+func (r *Registry) fetch(ctx context.Context) ([]Coin, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.opts.baseURL+coinGeckoCoinsListPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := r.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("coingecko get coins list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coingecko get coins list: %w %d", errUnexpectedStatusCode, resp.StatusCode)
+	}
+
+	var coins []Coin
+	if err := json.NewDecoder(resp.Body).Decode(&coins); err != nil {
+		return nil, fmt.Errorf("decode coins list: %w", err)
+	}
+
+	if len(coins) == 0 {
+		return nil, errEmptyCoinList
+	}
+
+	return coins, nil
+}
+
+func readCache(path string) ([]Coin, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read coin list cache: %w", err)
+	}
+
+	var coins []Coin
+	if err := json.Unmarshal(b, &coins); err != nil {
+		return nil, fmt.Errorf("unmarshal coin list cache: %w", err)
+	}
+
+	if len(coins) == 0 {
+		return nil, errEmptyCoinList
+	}
+
+	return coins, nil
+}
+
+// writeCache replaces the cache file through a temporary file, so that a crash never leaves it half written.
+func writeCache(path string, coins []Coin) error {
+	b, err := json.Marshal(coins)
+	if err != nil {
+		return fmt.Errorf("marshal coin list cache: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil { // nolint: gomnd
+		return fmt.Errorf("write coin list cache: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename coin list cache: %w", err)
+	}
+
+	return nil
+}
+
+func coinIDs(coins []Coin) []string {
+	res := make([]string, 0, len(coins))
+	for _, c := range coins {
+		res = append(res, c.ID)
+	}
+
+	return res
+}
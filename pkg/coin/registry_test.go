@@ -0,0 +1,142 @@
+package coin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCoinGecko serves the saved coin list until it is made unavailable.
+type fakeCoinGecko struct {
+	t           *testing.T
+	mu          sync.Mutex
+	unavailable bool
+	requests    int
+}
+
+func (s *fakeCoinGecko) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	unavailable := s.unavailable
+	s.mu.Unlock()
+
+	if r.URL.Path != coinGeckoCoinsListPath {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	if unavailable {
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		return
+	}
+
+	b, err := os.ReadFile(filepath.Join("testdata", "coins_list.json"))
+	require.NoError(s.t, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func (s *fakeCoinGecko) setUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unavailable = unavailable
+}
+
+func TestRegistry(t *testing.T) {
+	fake := &fakeCoinGecko{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	r := NewRegistry(
+		WithBaseURL(server.URL),
+		WithAllowlist("pepe"),
+		WithDenylist("LUNC"),
+	)
+	require.NoError(t, r.Load(context.Background()))
+
+	assert.True(t, r.Contains("SOL"))
+	assert.True(t, r.Contains("sui"))
+	assert.False(t, r.Contains("ARB"), "ambiguous symbol without confirmation")
+	assert.True(t, r.Contains("PEPE"), "ambiguous symbol confirmed by the allowlist")
+	assert.False(t, r.Contains("LUNC"), "denied symbol")
+	assert.False(t, r.Contains("DOGE"))
+
+	collisions := r.Collisions()
+	assert.Len(t, collisions, 2)
+	assert.Equal(t, []Coin{
+		{ID: "arbitrum", Symbol: "arb", Name: "Arbitrum"},
+		{ID: "arbidoge", Symbol: "arb", Name: "ArbiDoge"},
+	}, collisions["ARB"])
+
+	fake.setUnavailable(true)
+	assert.ErrorIs(t, r.Refresh(context.Background()), errUnexpectedStatusCode)
+	assert.True(t, r.Contains("SOL"), "the current list is kept on failure")
+}
+
+func TestRegistryCacheFallback(t *testing.T) {
+	fake := &fakeCoinGecko{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), "coins.json")
+
+	r := NewRegistry(WithBaseURL(server.URL), WithCacheFile(cacheFile))
+	require.NoError(t, r.Load(context.Background()))
+	require.FileExists(t, cacheFile)
+
+	fake.setUnavailable(true)
+
+	restarted := NewRegistry(WithBaseURL(server.URL), WithCacheFile(cacheFile))
+	require.NoError(t, restarted.Load(context.Background()))
+	assert.True(t, restarted.Contains("SOL"))
+
+	withoutCache := NewRegistry(WithBaseURL(server.URL), WithCacheFile(filepath.Join(t.TempDir(), "missing.json")))
+	err := withoutCache.Load(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Contains(t, err.Error(), errUnexpectedStatusCode.Error())
+}
+
+func TestSymbols(t *testing.T) {
+	var s Set = Symbols{"SOL": {}}
+
+	assert.True(t, s.Contains("SOL"))
+	assert.False(t, s.Contains("ETH"))
+}
+
+func TestRegistryRunRetriesUntilLoaded(t *testing.T) {
+	fake := &fakeCoinGecko{t: t, unavailable: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	r := NewRegistry(WithBaseURL(server.URL), WithRetryBackoff(time.Millisecond, 5*time.Millisecond))
+	require.Error(t, r.Load(context.Background()))
+	assert.False(t, r.Contains("SOL"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go r.Run(ctx, time.Hour)
+
+	assert.Eventually(t, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		return fake.requests >= 3
+	}, 5*time.Second, time.Millisecond)
+
+	fake.setUnavailable(false)
+
+	assert.Eventually(t, func() bool { return r.Contains("SOL") }, 5*time.Second, time.Millisecond)
+}
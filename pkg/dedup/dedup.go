@@ -0,0 +1,107 @@
+package dedup
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"go.uber.org/zap"
+)
+
+// ErrNotFound is returned by a Store for a key that has never been put.
+var ErrNotFound = errors.New("dedup key not found")
+
+// Store persists when each key was last let through so that duplicates are still caught after a restart.
+type Store interface {
+	PutDedupKey(key string, seenAt time.Time) error
+	// GetDedupKey returns when the key was last put, ErrNotFound if it has never been.
+	GetDedupKey(key string) (time.Time, error)
+}
+
+// Deduplicator tells whether a signal for a symbol has already been consumed with the same stage within the
+// window, regardless of which source the signals came from.
+type Deduplicator struct {
+	window time.Duration
+	store  Store
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+
+	now func() time.Time
+}
+
+func NewDeduplicator(window time.Duration, logger *zap.Logger, opts ...Option) *Deduplicator {
+	options := newDefaultOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &Deduplicator{
+		window:   window,
+		store:    options.store,
+		logger:   logger,
+		lastSeen: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Key is the symbol of the signal, followed by the stage if it has one, so that the later stage of a listing
+// is not dropped as a duplicate of the earlier one, and by the side if it is a sell signal.
+func Key(s api.BuySignal) string {
+	key := strings.ToUpper(strings.TrimSpace(s.Symbol))
+	if s.Stage != api.SignalStageUnspecified {
+		key += "/" + string(s.Stage)
+	}
+
+	if s.IsSell() {
+		key += "/" + string(api.SignalSideSell)
+	}
+
+	return key
+}
+
+// IsDuplicate reports whether the signal has been marked as seen within the window.
+func (d *Deduplicator) IsDuplicate(s api.BuySignal) bool {
+	key := Key(s)
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lastSeen, ok := d.lastSeen[key]
+	if !ok && d.store != nil {
+		seenAt, err := d.store.GetDedupKey(key)
+
+		switch {
+		case err == nil:
+			lastSeen, ok = seenAt, true
+			d.lastSeen[key] = seenAt
+		case errors.Is(err, ErrNotFound):
+		default:
+			d.logger.Error("Fail to get dedup key", zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	return ok && now.Sub(lastSeen) < d.window
+}
+
+// MarkSeen marks the signal as seen, it is called once the signal has been consumed so that a signal failing
+// to be consumed is not dropped when it comes again, e.g. from another source.
+func (d *Deduplicator) MarkSeen(s api.BuySignal) {
+	key := Key(s)
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastSeen[key] = now
+
+	if d.store != nil {
+		if err := d.store.PutDedupKey(key, now); err != nil {
+			d.logger.Error("Fail to put dedup key", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type memoryStore map[string]time.Time
+
+func (s memoryStore) PutDedupKey(key string, seenAt time.Time) error {
+	s[key] = seenAt
+
+	return nil
+}
+
+func (s memoryStore) GetDedupKey(key string) (time.Time, error) {
+	seenAt, ok := s[key]
+	if !ok {
+		return time.Time{}, ErrNotFound
+	}
+
+	return seenAt, nil
+}
+
+// consume marks the signal as seen unless it is a duplicate, as a consumer does once it has been consumed.
+func consume(d *Deduplicator, s api.BuySignal) bool {
+	if d.IsDuplicate(s) {
+		return false
+	}
+
+	d.MarkSeen(s)
+
+	return true
+}
+
+func TestIsDuplicate(t *testing.T) {
+	now := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+	d := NewDeduplicator(time.Hour, zap.NewNop())
+	d.now = func() time.Time { return now }
+
+	assert.True(t, consume(d, api.BuySignal{Symbol: "SHIB", Source: "https://twitter.com/CoinbasePro/status/1"}))
+	assert.False(t, consume(d, api.BuySignal{Symbol: "shib", Source: "https://twitter.com/CoinbasePro/status/2"}))
+	assert.True(t, consume(d, api.BuySignal{Symbol: "CHZ", Source: "https://twitter.com/CoinbasePro/status/1"}))
+
+	now = now.Add(time.Hour)
+	assert.True(t, consume(d, api.BuySignal{Symbol: "SHIB", Source: "https://twitter.com/CoinbasePro/status/3"}))
+}
+
+func TestIsDuplicateByStage(t *testing.T) {
+	d := NewDeduplicator(time.Hour, zap.NewNop())
+
+	assert.True(t, consume(d, api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTransfers}))
+	assert.True(t, consume(d, api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTradingLive}))
+	assert.False(t, consume(d, api.BuySignal{Symbol: "sol", Stage: api.SignalStageTradingLive}))
+	assert.True(t, consume(d, api.BuySignal{Symbol: "SOL"}))
+	assert.True(t, consume(d, api.BuySignal{Symbol: "SOL", Side: api.SignalSideSell}))
+	assert.False(t, consume(d, api.BuySignal{Symbol: "SOL", Side: api.SignalSideBuy}))
+}
+
+func TestIsDuplicateWithStore(t *testing.T) {
+	store := memoryStore{}
+	now := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	d := NewDeduplicator(time.Hour, zap.NewNop(), WithStore(store))
+	d.now = func() time.Time { return now }
+	assert.True(t, consume(d, api.BuySignal{Symbol: "SHIB"}))
+
+	restarted := NewDeduplicator(time.Hour, zap.NewNop(), WithStore(store))
+	restarted.now = func() time.Time { return now.Add(30 * time.Minute) }
+	assert.False(t, consume(restarted, api.BuySignal{Symbol: "SHIB"}))
+	assert.True(t, consume(restarted, api.BuySignal{Symbol: "KEEP"}))
+}
+
+func TestIsDuplicateBeforeMarkSeen(t *testing.T) {
+	d := NewDeduplicator(time.Hour, zap.NewNop())
+
+	assert.False(t, d.IsDuplicate(api.BuySignal{Symbol: "SOL"}))
+	assert.False(t, d.IsDuplicate(api.BuySignal{Symbol: "SOL"}), "a signal that failed to be consumed comes through again")
+
+	d.MarkSeen(api.BuySignal{Symbol: "SOL"})
+	assert.True(t, d.IsDuplicate(api.BuySignal{Symbol: "SOL"}))
+}
@@ -0,0 +1,28 @@
+package dedup
+
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	store Store
+}
+
+func newDefaultOptions() options {
+	return options{
+		store: nil,
+	}
+}
+
+type storeOption struct {
+	store Store
+}
+
+func (c storeOption) apply(opts *options) {
+	opts.store = c.store
+}
+
+// WithStore persists the dedup history, e.g. into the journal.
+func WithStore(store Store) Option {
+	return storeOption{store: store}
+}
@@ -0,0 +1,450 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/lht102/ctrade/pkg/tweet"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+const SourceName = "feed"
+
+var (
+	errUnexpectedStatusCode = errors.New("unexpected status code")
+	errUnknownFeedFormat    = errors.New("unknown feed format")
+	errUnknownFeedFile      = errors.New("unknown feed file format")
+	errEmptyFeedURL         = errors.New("feed without url")
+)
+
+// Feed is an RSS or Atom feed and the patterns its items are matched against. The feed name is the
+// account of the patterns, patterns without accounts match any feed.
+type Feed struct {
+	Name     string
+	URL      string
+	Patterns *tweet.PatternLibrary
+}
+
+type feedFile struct {
+	Feeds []feedConfig `json:"feeds" yaml:"feeds"`
+}
+
+type feedConfig struct {
+	Name     string          `json:"name" yaml:"name"`
+	URL      string          `json:"url" yaml:"url"`
+	Patterns []tweet.Pattern `json:"patterns" yaml:"patterns"`
+}
+
+// LoadFeeds reads the feeds and their patterns from a .yaml, .yml or .json file, an unknown field is rejected in
+// both formats.
+func LoadFeeds(path string) ([]Feed, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read feed file: %w", err)
+	}
+
+	var f feedFile
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(b, &f)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownFeedFile, path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal feed file: %w", err)
+	}
+
+	res := make([]Feed, 0, len(f.Feeds))
+
+	for _, c := range f.Feeds {
+		if c.URL == "" {
+			return nil, fmt.Errorf("%w: %s", errEmptyFeedURL, c.Name)
+		}
+
+		patterns, err := tweet.NewPatternLibrary(c.Patterns...)
+		if err != nil {
+			return nil, fmt.Errorf("patterns of feed %s: %w", c.Name, err)
+		}
+
+		res = append(res, Feed{
+			Name:     c.Name,
+			URL:      c.URL,
+			Patterns: patterns,
+		})
+	}
+
+	return res, nil
+}
+
+type item struct {
+	ID      string
+	Title   string
+	Link    string
+	Summary string
+	// PublishedAt is zero if the feed does not have it or it cannot be parsed.
+	PublishedAt time.Time
+}
+
+// document covers both formats, the items of an RSS feed are in the channel and the entries of
+// an Atom feed are at the root.
+type document struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []struct {
+			GUID        string `xml:"guid"`
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// parseFeed returns the items of an RSS 2.0 or Atom feed in the order of the document, usually newest first.
+func parseFeed(b []byte) ([]item, error) {
+	var doc document
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshal feed: %w", err)
+	}
+
+	res := []item{}
+
+	switch doc.XMLName.Local {
+	case "rss":
+		for _, i := range doc.Channel.Items {
+			res = append(res, item{
+				ID:          firstNonEmpty(i.GUID, i.Link, i.Title),
+				Title:       strings.TrimSpace(i.Title),
+				Link:        strings.TrimSpace(i.Link),
+				Summary:     strings.TrimSpace(i.Description),
+				PublishedAt: parseTime(i.PubDate, rssTimeLayouts...),
+			})
+		}
+	case "feed":
+		for _, e := range doc.Entries {
+			link := ""
+
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+
+					break
+				}
+			}
+
+			res = append(res, item{
+				ID:          firstNonEmpty(e.ID, link, e.Title),
+				Title:       strings.TrimSpace(e.Title),
+				Link:        link,
+				Summary:     strings.TrimSpace(firstNonEmpty(e.Summary, e.Content)),
+				PublishedAt: parseTime(firstNonEmpty(e.Published, e.Updated), time.RFC3339),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownFeedFormat, doc.XMLName.Local)
+	}
+
+	return res, nil
+}
+
+// rssTimeLayouts are the RFC 822 dates of RSS, with and without the day of the week and a numeric zone.
+var rssTimeLayouts = []string{ // nolint: gochecknoglobals
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+}
+
+// parseTime returns the time in UTC by the first layout that parses it, zero if none does.
+func parseTime(s string, layouts ...string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+
+	return ""
+}
+
+type feedState struct {
+	Feed
+
+	etag         string
+	lastModified string
+	seen         map[string]struct{}
+	primed       bool
+}
+
+// Source polls RSS and Atom feeds with conditional requests and emits a signal for every supported symbol
+// of a new item whose title, or summary, matches the patterns of its feed.
+type Source struct {
+	feeds          []*feedState
+	supportedCoins coin.Set
+	opts           options
+
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewSource(feeds []Feed, supportedCoins coin.Set, opts ...Option) *Source {
+	options := newDefaultOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	states := make([]*feedState, 0, len(feeds))
+	for _, f := range feeds {
+		states = append(states, &feedState{
+			Feed: f,
+			seen: make(map[string]struct{}),
+		})
+	}
+
+	return &Source{
+		feeds:          states,
+		supportedCoins: supportedCoins,
+		opts:           options,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *Source) Name() string {
+	return SourceName
+}
+
+// Start marks the items already in the feeds as seen, so that only the ones published afterwards
+// produce signals, and then polls in the background until Stop. A feed that cannot be fetched at start
+// is marked on its first successful poll instead.
+func (s *Source) Start() (<-chan api.Signal, error) {
+	_ = s.poll(context.Background())
+
+	signalCh := make(chan api.Signal)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer close(signalCh)
+
+		ticker := time.NewTicker(s.opts.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+
+			for _, v := range s.poll(context.Background()) {
+				select {
+				case <-s.done:
+					return
+				case signalCh <- v:
+				}
+			}
+		}
+	}()
+
+	return signalCh, nil
+}
+
+func (s *Source) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+
+	s.wg.Wait()
+}
+
+// poll returns the signals of the new items of every feed, the feeds that fail are logged and skipped.
+func (s *Source) poll(ctx context.Context) []api.Signal {
+	res := []api.Signal{}
+
+	for _, f := range s.feeds {
+		signals, err := s.pollFeed(ctx, f)
+		if err != nil {
+			s.opts.logger.Error("Fail to poll feed", zap.String("feed", f.Name), zap.Error(err))
+
+			continue
+		}
+
+		res = append(res, signals...)
+	}
+
+	return res
+}
+
+// pollFeed returns the signals of the items not seen before, oldest first.
+func (s *Source) pollFeed(ctx context.Context, f *feedState) ([]api.Signal, error) {
+	items, err := s.fetch(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	// not modified
+	if items == nil {
+		return nil, nil
+	}
+
+	res := []api.Signal{}
+	seen := make(map[string]struct{}, len(items))
+
+	for i := len(items) - 1; i >= 0; i-- {
+		it := items[i]
+		seen[it.ID] = struct{}{}
+
+		if _, ok := f.seen[it.ID]; ok || !f.primed {
+			continue
+		}
+
+		res = append(res, s.handleItem(f, it)...)
+	}
+
+	// only the items still in the feed are kept, the feed is a window over the latest items
+	f.seen = seen
+	f.primed = true
+
+	return res, nil
+}
+
+func (s *Source) handleItem(f *feedState, it item) []api.Signal {
+	text := it.Title
+
+	p, similarity := f.Patterns.Match(f.Name, text)
+	if p == nil && it.Summary != "" {
+		text = it.Summary
+		p, similarity = f.Patterns.Match(f.Name, text)
+	}
+
+	if p == nil {
+		return nil
+	}
+
+	res := []api.Signal{}
+	detectedAt := time.Now()
+
+	for _, symbol := range p.ExtractSymbols(text) {
+		if !s.supportedCoins.Contains(symbol) {
+			continue
+		}
+
+		s.opts.logger.Info(
+			"Detected feed item",
+			zap.String("feed", f.Name),
+			zap.String("pattern", p.Name),
+			zap.String("symbol", symbol),
+			zap.String("title", it.Title),
+		)
+
+		signal := api.NewSignal(api.BuySignal{
+			Symbol: symbol,
+			Source: firstNonEmpty(it.Link, f.URL),
+			Stage:  p.Stage,
+			Side:   p.Side,
+		}, api.SourceKindFeed, detectedAt)
+		signal.EventAt = it.PublishedAt
+		signal.Confidence = similarity
+		signal.RawText = text
+		signal.Metadata = map[string]string{"feed": f.Name, "pattern": p.Name}
+
+		res = append(res, signal)
+	}
+
+	return res
+}
+
+// fetch returns the items of the feed, nil if it has not been modified since the last fetch.
+func (s *Source) fetch(ctx context.Context, f *feedState) ([]item, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	if f.etag != "" {
+		req.Header.Set("If-None-Match", f.etag)
+	}
+
+	if f.lastModified != "" {
+		req.Header.Set("If-Modified-Since", f.lastModified)
+	}
+
+	resp, err := s.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get feed: %w %d", errUnexpectedStatusCode, resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read feed: %w", err)
+	}
+
+	items, err := parseFeed(b)
+	if err != nil {
+		return nil, err
+	}
+
+	f.etag = resp.Header.Get("ETag")
+	f.lastModified = resp.Header.Get("Last-Modified")
+
+	return items, nil
+}
@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/lht102/ctrade/pkg/tweet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureServer serves the saved feeds of each path in order, the last one is repeated. The fixture
+// name is the validator of the response, a conditional request with it gets 304.
+type fixtureServer struct {
+	t           *testing.T
+	mu          sync.Mutex
+	fixtures    map[string][]string
+	notModified int
+}
+
+func (s *fixtureServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	fixtures, ok := s.fixtures[r.URL.Path]
+	if !ok {
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	name := fixtures[0]
+	if len(fixtures) > 1 {
+		s.fixtures[r.URL.Path] = fixtures[1:]
+	}
+
+	if r.Header.Get("If-None-Match") == `"`+name+`"` || r.Header.Get("If-Modified-Since") == name {
+		s.notModified++
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+	s.mu.Unlock()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(s.t, err)
+
+	if filepath.Ext(r.URL.Path) == ".rss" {
+		w.Header().Set("ETag", `"`+name+`"`)
+	} else {
+		w.Header().Set("Last-Modified", name)
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write(b)
+}
+
+func TestParseFeed(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "status_updated.xml"))
+	require.NoError(t, err)
+
+	items, err := parseFeed(b)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, item{
+		ID:          "https://status.coinbase.com/incidents/sui",
+		Title:       "Inbound transfers for Sui (SUI) are now available",
+		Link:        "https://status.coinbase.com/incidents/sui",
+		Summary:     "Inbound transfers for SUI are now available in the regions where trading is supported.",
+		PublishedAt: time.Date(2023, 3, 22, 9, 0, 0, 0, time.UTC),
+	}, items[1])
+
+	b, err = os.ReadFile(filepath.Join("testdata", "blog_updated.xml"))
+	require.NoError(t, err)
+
+	items, err = parseFeed(b)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, item{
+		ID:          "urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6c",
+		Title:       "Delisting notice",
+		Link:        "https://blog.example.com/delisting-notice",
+		Summary:     "We will delist MIR and NOTSUPPORTED on 2023-03-31.",
+		PublishedAt: time.Date(2023, 3, 22, 11, 30, 0, 0, time.UTC),
+	}, items[0])
+	assert.Equal(t, time.Date(2023, 3, 21, 16, 0, 0, 0, time.UTC), items[1].PublishedAt, "the updated time is used without the published one")
+
+	_, err = parseFeed([]byte(`<html><body>maintenance</body></html>`))
+	assert.ErrorIs(t, err, errUnknownFeedFormat)
+}
+
+func TestLoadFeeds(t *testing.T) {
+	feeds, err := LoadFeeds(filepath.Join("..", "..", "feeds.sample.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, feeds)
+
+	for _, f := range feeds {
+		assert.NotEmpty(t, f.URL)
+		assert.NotEmpty(t, f.Patterns.Patterns)
+	}
+
+	path := filepath.Join(t.TempDir(), "feeds.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("feeds:\n  - name: no_url\n"), 0o600))
+
+	_, err = LoadFeeds(path)
+	assert.ErrorIs(t, err, errEmptyFeedURL)
+
+	path = filepath.Join(t.TempDir(), "feeds.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"feeds":[{"name":"a","link":"https://example.com/feed"}]}`), 0o600))
+
+	_, err = LoadFeeds(path)
+	assert.Error(t, err, "an unknown field is rejected")
+}
+
+func TestSource(t *testing.T) {
+	statusPatterns, err := tweet.NewPatternLibrary(tweet.Pattern{
+		Name:      "coinbase_status_inbound_transfers",
+		Templates: []string{"Inbound transfers for Xxx (XXX) are now available"},
+		Keywords:  []string{"Inbound transfers"},
+		Extraction: tweet.ExtractionRule{
+			Type:  tweet.ExtractionRegex,
+			Regex: `\(([A-Z0-9]+)\)`,
+		},
+		Stage: api.SignalStageTransfers,
+	})
+	require.NoError(t, err)
+
+	blogPatterns, err := tweet.NewPatternLibrary(tweet.Pattern{
+		Name:      "blog_delisting",
+		Accounts:  []string{"blog"},
+		Templates: []string{"We will delist XXX and XXX on 2023-01-01."},
+		Keywords:  []string{"delist"},
+		Extraction: tweet.ExtractionRule{
+			Type:  tweet.ExtractionRegex,
+			Regex: `\b[A-Z][A-Z0-9]+\b`,
+		},
+		Side: api.SignalSideSell,
+	})
+	require.NoError(t, err)
+
+	fixtures := &fixtureServer{
+		t: t,
+		fixtures: map[string][]string{
+			"/status.rss": {"status_initial.xml", "status_initial.xml", "status_updated.xml"},
+			"/blog.atom":  {"blog_initial.xml", "blog_initial.xml", "blog_updated.xml"},
+		},
+	}
+	server := httptest.NewServer(fixtures)
+	defer server.Close()
+
+	s := NewSource(
+		[]Feed{
+			{Name: "coinbase_status", URL: server.URL + "/status.rss", Patterns: statusPatterns},
+			{Name: "down", URL: server.URL + "/down.rss", Patterns: statusPatterns},
+			{Name: "blog", URL: server.URL + "/blog.atom", Patterns: blogPatterns},
+		},
+		coin.Symbols{"ARB": {}, "SUI": {}, "SOL": {}, "MIR": {}},
+		WithPollInterval(10*time.Millisecond),
+	)
+
+	ch, err := s.Start()
+	require.NoError(t, err)
+
+	signals := []api.BuySignal{}
+
+	for len(signals) < 2 {
+		select {
+		case v := <-ch:
+			assert.Equal(t, api.SourceKindFeed, v.SourceKind)
+			assert.False(t, v.EventAt.IsZero())
+			assert.Greater(t, v.Confidence, 0.5, "the confidence is the similarity to the pattern")
+			assert.LessOrEqual(t, v.Confidence, 1.0)
+			signals = append(signals, v.BuySignal())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for signals")
+		}
+	}
+
+	s.Stop()
+	s.Stop()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	assert.Equal(t, []api.BuySignal{
+		{Symbol: "SUI", Source: "https://status.coinbase.com/incidents/sui", Stage: api.SignalStageTransfers, Side: api.SignalSideBuy},
+		{Symbol: "MIR", Source: "https://blog.example.com/delisting-notice", Side: api.SignalSideSell},
+	}, signals)
+
+	fixtures.mu.Lock()
+	defer fixtures.mu.Unlock()
+
+	assert.GreaterOrEqual(t, fixtures.notModified, 2)
+}
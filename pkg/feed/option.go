@@ -0,0 +1,65 @@
+package feed
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	defaultHTTPTimeout  = 10 * time.Second
+)
+
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	httpClient   *http.Client
+	pollInterval time.Duration
+	logger       *zap.Logger
+}
+
+func newDefaultOptions() options {
+	return options{
+		httpClient:   &http.Client{Timeout: defaultHTTPTimeout},
+		pollInterval: defaultPollInterval,
+		logger:       zap.NewNop(),
+	}
+}
+
+type httpClientOption struct {
+	httpClient *http.Client
+}
+
+func (c httpClientOption) apply(opts *options) {
+	opts.httpClient = c.httpClient
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return httpClientOption{httpClient: httpClient}
+}
+
+type pollIntervalOption time.Duration
+
+func (c pollIntervalOption) apply(opts *options) {
+	opts.pollInterval = time.Duration(c)
+}
+
+func WithPollInterval(d time.Duration) Option {
+	return pollIntervalOption(d)
+}
+
+type loggerOption struct {
+	logger *zap.Logger
+}
+
+func (c loggerOption) apply(opts *options) {
+	opts.logger = c.logger
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return loggerOption{logger: logger}
+}
@@ -0,0 +1,365 @@
+package journal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lht102/ctrade/pkg/dedup"
+	bolt "go.etcd.io/bbolt"
+)
+
+const openTimeout = time.Second
+
+var ErrNotFound = errors.New("journal record not found")
+
+var (
+	signalsBucket   = []byte("signals")    // nolint: gochecknoglobals
+	dedupKeysBucket = []byte("dedup_keys") // nolint: gochecknoglobals
+	ordersBucket    = []byte("orders")     // nolint: gochecknoglobals
+	fillsBucket     = []byte("fills")      // nolint: gochecknoglobals
+	deadlinesBucket = []byte("deadlines")  // nolint: gochecknoglobals
+)
+
+type OrderKind string
+
+const (
+	OrderKindEntry OrderKind = "entry"
+	OrderKindExit  OrderKind = "exit"
+)
+
+type SignalRecord struct {
+	ID         uint64    `json:"id"`
+	Symbol     string    `json:"symbol"`
+	Source     string    `json:"source"`
+	Stage      string    `json:"stage,omitempty"`
+	Side       string    `json:"side,omitempty"`
+	DedupKey   string    `json:"dedupKey"`
+	ReceivedAt time.Time `json:"receivedAt"`
+
+	SourceKind string `json:"sourceKind,omitempty"`
+	// DetectedAt and EventAt are when the source detected the signal and when the original event was
+	// published, EventAt is zero if unknown.
+	DetectedAt time.Time `json:"detectedAt"`
+	EventAt    time.Time `json:"eventAt"`
+}
+
+type OrderRecord struct {
+	Symbol           string    `json:"symbol"`
+	OrderID          int64     `json:"orderId"`
+	Kind             OrderKind `json:"kind"`
+	Side             string    `json:"side"`
+	Type             string    `json:"type"`
+	Status           string    `json:"status"`
+	Quantity         string    `json:"quantity"`
+	Price            string    `json:"price"`
+	StopPrice        string    `json:"stopPrice"`
+	ExecutedQuantity string    `json:"executedQuantity"`
+	AvgPrice         string    `json:"avgPrice"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type FillRecord struct {
+	ID       uint64    `json:"id"`
+	Symbol   string    `json:"symbol"`
+	OrderID  int64     `json:"orderId"`
+	Side     string    `json:"side"`
+	Quantity string    `json:"quantity"`
+	Price    string    `json:"price"`
+	FilledAt time.Time `json:"filledAt"`
+}
+
+// Journal is an append mostly record of signals, orders and fills kept in a BoltDB file.
+type Journal struct {
+	db *bolt.DB
+}
+
+func Open(path string) (*Journal, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout}) // nolint: gomnd
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{signalsBucket, dedupKeysBucket, ordersBucket, fillsBucket, deadlinesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return fmt.Errorf("create bucket %s: %w", b, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("init journal: %w", err)
+	}
+
+	return &Journal{
+		db: db,
+	}, nil
+}
+
+func (j *Journal) Close() error {
+	if err := j.db.Close(); err != nil {
+		return fmt.Errorf("close journal: %w", err)
+	}
+
+	return nil
+}
+
+func (j *Journal) RecordSignal(r SignalRecord) (uint64, error) {
+	err := j.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(signalsBucket)
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return fmt.Errorf("next signal id: %w", err)
+		}
+
+		r.ID = id
+
+		return put(b, itob(id), r)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return r.ID, nil
+}
+
+// ListSignals returns the signals received at or after since in the order they were recorded.
+func (j *Journal) ListSignals(since time.Time) ([]SignalRecord, error) {
+	res := []SignalRecord{}
+
+	err := j.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(signalsBucket).ForEach(func(k, v []byte) error {
+			var r SignalRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("unmarshal signal: %w", err)
+			}
+
+			if !r.ReceivedAt.Before(since) {
+				res = append(res, r)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list signals: %w", err)
+	}
+
+	return res, nil
+}
+
+func (j *Journal) PutDedupKey(key string, seenAt time.Time) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(dedupKeysBucket), []byte(key), seenAt)
+	})
+}
+
+// GetDedupKey returns when the key was last seen, dedup.ErrNotFound if it has never been seen.
+func (j *Journal) GetDedupKey(key string) (time.Time, error) {
+	var seenAt time.Time
+
+	err := j.db.View(func(tx *bolt.Tx) error {
+		return get(tx.Bucket(dedupKeysBucket), []byte(key), &seenAt)
+	})
+	if errors.Is(err, ErrNotFound) {
+		return time.Time{}, dedup.ErrNotFound
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return seenAt, nil
+}
+
+// RecordOrder inserts the order or replaces the previous record with the same symbol and order id.
+// CreatedAt of the first record is kept.
+func (j *Journal) RecordOrder(r OrderRecord) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(ordersBucket)
+		key := orderKey(r.Symbol, r.OrderID)
+
+		var prev OrderRecord
+
+		err := get(b, key, &prev)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		if err == nil && !prev.CreatedAt.IsZero() {
+			r.CreatedAt = prev.CreatedAt
+		}
+
+		return put(b, key, r)
+	})
+}
+
+func (j *Journal) GetOrder(symbol string, orderID int64) (OrderRecord, error) {
+	var r OrderRecord
+
+	err := j.db.View(func(tx *bolt.Tx) error {
+		return get(tx.Bucket(ordersBucket), orderKey(symbol, orderID), &r)
+	})
+	if err != nil {
+		return OrderRecord{}, err
+	}
+
+	return r, nil
+}
+
+// ListOrders returns the orders of the symbol ordered by order id, all orders if symbol is empty.
+func (j *Journal) ListOrders(symbol string) ([]OrderRecord, error) {
+	res := []OrderRecord{}
+
+	err := j.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(ordersBucket).Cursor()
+		prefix := []byte(symbol + "/")
+
+		k, v := c.Seek(prefix)
+		if symbol == "" {
+			k, v = c.First()
+		}
+
+		for ; k != nil && (symbol == "" || strings.HasPrefix(string(k), string(prefix))); k, v = c.Next() {
+			var r OrderRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("unmarshal order: %w", err)
+			}
+
+			res = append(res, r)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list orders: %w", err)
+	}
+
+	return res, nil
+}
+
+func (j *Journal) RecordFill(r FillRecord) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(fillsBucket)
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return fmt.Errorf("next fill id: %w", err)
+		}
+
+		r.ID = id
+
+		return put(b, itob(id), r)
+	})
+}
+
+// ListFills returns the fills of the symbol in the order they were recorded, all fills if symbol is empty.
+func (j *Journal) ListFills(symbol string) ([]FillRecord, error) {
+	res := []FillRecord{}
+
+	err := j.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(fillsBucket).ForEach(func(k, v []byte) error {
+			var r FillRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("unmarshal fill: %w", err)
+			}
+
+			if symbol == "" || r.Symbol == symbol {
+				res = append(res, r)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list fills: %w", err)
+	}
+
+	return res, nil
+}
+
+func (j *Journal) SaveDeadline(symbol string, deadline time.Time) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(deadlinesBucket), []byte(symbol), deadline)
+	})
+}
+
+func (j *Journal) DeleteDeadline(symbol string) error {
+	err := j.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadlinesBucket).Delete([]byte(symbol))
+	})
+	if err != nil {
+		return fmt.Errorf("delete deadline: %w", err)
+	}
+
+	return nil
+}
+
+func (j *Journal) LoadDeadlines() (map[string]time.Time, error) {
+	res := make(map[string]time.Time)
+
+	err := j.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadlinesBucket).ForEach(func(k, v []byte) error {
+			var deadline time.Time
+			if err := json.Unmarshal(v, &deadline); err != nil {
+				return fmt.Errorf("unmarshal deadline: %w", err)
+			}
+
+			res[string(k)] = deadline
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load deadlines: %w", err)
+	}
+
+	return res, nil
+}
+
+func put(b *bolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal journal record: %w", err)
+	}
+
+	if err := b.Put(key, data); err != nil {
+		return fmt.Errorf("put journal record: %w", err)
+	}
+
+	return nil
+}
+
+func get(b *bolt.Bucket, key []byte, v interface{}) error {
+	data := b.Get(key)
+	if data == nil {
+		return ErrNotFound
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unmarshal journal record: %w", err)
+	}
+
+	return nil
+}
+
+// orderKey zero pads the order id so that the orders of a symbol are sorted by id.
+func orderKey(symbol string, orderID int64) []byte {
+	return []byte(fmt.Sprintf("%s/%020d", symbol, orderID))
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8) // nolint: gomnd
+	binary.BigEndian.PutUint64(b, v)
+
+	return b
+}
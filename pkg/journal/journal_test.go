@@ -0,0 +1,114 @@
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/pkg/dedup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestJournal(t *testing.T) *Journal {
+	t.Helper()
+
+	j, err := Open(filepath.Join(t.TempDir(), "journal.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, j.Close())
+	})
+
+	return j
+}
+
+func TestSignals(t *testing.T) {
+	j := openTestJournal(t)
+	start := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	id, err := j.RecordSignal(SignalRecord{Symbol: "CHZ", Source: "https://twitter.com/CoinbasePro/status/1", ReceivedAt: start})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), id)
+
+	id, err = j.RecordSignal(SignalRecord{Symbol: "SHIB", Source: "https://twitter.com/CoinbasePro/status/1", ReceivedAt: start.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), id)
+
+	signals, err := j.ListSignals(start.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, signals, 1)
+	assert.Equal(t, "SHIB", signals[0].Symbol)
+	assert.Equal(t, uint64(2), signals[0].ID)
+}
+
+func TestDedupKeys(t *testing.T) {
+	j := openTestJournal(t)
+	seenAt := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	_, err := j.GetDedupKey("SHIB")
+	assert.ErrorIs(t, err, dedup.ErrNotFound)
+
+	require.NoError(t, j.PutDedupKey("SHIB", seenAt))
+
+	res, err := j.GetDedupKey("SHIB")
+	require.NoError(t, err)
+	assert.True(t, seenAt.Equal(res))
+}
+
+func TestOrders(t *testing.T) {
+	j := openTestJournal(t)
+	createdAt := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	require.NoError(t, j.RecordOrder(OrderRecord{Symbol: "SOLUSDT", OrderID: 10, Kind: OrderKindEntry, Status: "NEW", CreatedAt: createdAt}))
+	require.NoError(t, j.RecordOrder(OrderRecord{Symbol: "SOLUSDT", OrderID: 9, Kind: OrderKindExit, Status: "NEW", CreatedAt: createdAt}))
+	require.NoError(t, j.RecordOrder(OrderRecord{Symbol: "SOLUSDTM", OrderID: 1, Kind: OrderKindEntry, Status: "NEW", CreatedAt: createdAt}))
+	require.NoError(t, j.RecordOrder(OrderRecord{
+		Symbol:    "SOLUSDT",
+		OrderID:   10,
+		Kind:      OrderKindEntry,
+		Status:    "FILLED",
+		CreatedAt: createdAt.Add(time.Minute),
+		UpdatedAt: createdAt.Add(time.Minute),
+	}))
+
+	o, err := j.GetOrder("SOLUSDT", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "FILLED", o.Status)
+	assert.True(t, createdAt.Equal(o.CreatedAt))
+
+	_, err = j.GetOrder("SOLUSDT", 11)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	orders, err := j.ListOrders("SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, int64(9), orders[0].OrderID)
+	assert.Equal(t, int64(10), orders[1].OrderID)
+
+	orders, err = j.ListOrders("")
+	require.NoError(t, err)
+	assert.Len(t, orders, 3)
+}
+
+func TestFillsAndDeadlines(t *testing.T) {
+	j := openTestJournal(t)
+	now := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	require.NoError(t, j.RecordFill(FillRecord{Symbol: "SOLUSDT", OrderID: 1, Quantity: "2.5", Price: "40", FilledAt: now}))
+	require.NoError(t, j.RecordFill(FillRecord{Symbol: "DOGEUSDT", OrderID: 2, Quantity: "100", Price: "0.3", FilledAt: now}))
+
+	fills, err := j.ListFills("SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, fills, 1)
+	assert.Equal(t, "2.5", fills[0].Quantity)
+
+	require.NoError(t, j.SaveDeadline("SOLUSDT", now))
+	require.NoError(t, j.SaveDeadline("DOGEUSDT", now))
+	require.NoError(t, j.DeleteDeadline("DOGEUSDT"))
+
+	deadlines, err := j.LoadDeadlines()
+	require.NoError(t, err)
+	require.Len(t, deadlines, 1)
+	assert.True(t, now.Equal(deadlines["SOLUSDT"]))
+}
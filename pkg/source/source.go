@@ -0,0 +1,156 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/lht102/ctrade/api"
+	"go.uber.org/zap"
+)
+
+var (
+	errUnknownSource   = errors.New("unknown signal source")
+	errDuplicateSource = errors.New("duplicate signal source")
+	errAlreadyStarted  = errors.New("signal sources already started")
+)
+
+// SignalSource produces signals until it is stopped.
+type SignalSource interface {
+	Name() string
+	// Start begins producing signals into the returned channel, which is closed after Stop.
+	Start() (<-chan api.Signal, error)
+	Stop()
+}
+
+// Factory builds a signal source, it is only called when the source is enabled.
+type Factory func() (SignalSource, error)
+
+// Registry keeps the known signal sources by name and runs the enabled ones.
+type Registry struct {
+	logger    *zap.Logger
+	factories map[string]Factory
+
+	mu      sync.Mutex
+	running []SignalSource
+}
+
+func NewRegistry(logger *zap.Logger) *Registry {
+	return &Registry{
+		logger:    logger,
+		factories: make(map[string]Factory),
+	}
+}
+
+func (r *Registry) Register(name string, factory Factory) error {
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("%w: %s", errDuplicateSource, name)
+	}
+
+	r.factories[name] = factory
+
+	return nil
+}
+
+// Names returns the registered source names in alphabetical order.
+func (r *Registry) Names() []string {
+	res := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// Start builds and starts the named sources, and merges their signals into one channel.
+// The sources started before a failure are stopped again.
+func (r *Registry) Start(names ...string) (<-chan api.Signal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.running) > 0 {
+		return nil, errAlreadyStarted
+	}
+
+	channels := make([]<-chan api.Signal, 0, len(names))
+
+	for _, name := range names {
+		ch, err := r.start(name)
+		if err != nil {
+			r.stop()
+
+			return nil, err
+		}
+
+		channels = append(channels, ch)
+	}
+
+	return merge(channels), nil
+}
+
+// Stop stops every running source, the merged channel is closed once all of them are done.
+func (r *Registry) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stop()
+}
+
+func (r *Registry) start(name string) (<-chan api.Signal, error) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownSource, name)
+	}
+
+	s, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("build signal source %s: %w", name, err)
+	}
+
+	ch, err := s.Start()
+	if err != nil {
+		return nil, fmt.Errorf("start signal source %s: %w", name, err)
+	}
+
+	r.running = append(r.running, s)
+	r.logger.Info("Started signal source", zap.String("source", s.Name()))
+
+	return ch, nil
+}
+
+func (r *Registry) stop() {
+	for i := len(r.running) - 1; i >= 0; i-- {
+		r.running[i].Stop()
+		r.logger.Info("Stopped signal source", zap.String("source", r.running[i].Name()))
+	}
+
+	r.running = nil
+}
+
+func merge(channels []<-chan api.Signal) <-chan api.Signal {
+	out := make(chan api.Signal)
+
+	var wg sync.WaitGroup
+
+	wg.Add(len(channels))
+
+	for _, ch := range channels {
+		go func(ch <-chan api.Signal) {
+			defer wg.Done()
+
+			for s := range ch {
+				out <- s
+			}
+		}(ch)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,98 @@
+package source
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var errStartFailed = errors.New("start failed")
+
+type fakeSource struct {
+	name     string
+	signals  []api.Signal
+	startErr error
+
+	ch      chan api.Signal
+	stopped bool
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) Start() (<-chan api.Signal, error) {
+	if s.startErr != nil {
+		return nil, s.startErr
+	}
+
+	s.ch = make(chan api.Signal, len(s.signals))
+	for _, v := range s.signals {
+		s.ch <- v
+	}
+
+	return s.ch, nil
+}
+
+func (s *fakeSource) Stop() {
+	s.stopped = true
+	close(s.ch)
+}
+
+func TestRegistry(t *testing.T) {
+	a := &fakeSource{name: "a", signals: []api.Signal{{Symbol: "SOL", Source: "a"}}}
+	b := &fakeSource{name: "b", signals: []api.Signal{{Symbol: "ETH", Source: "b"}, {Symbol: "DOT", Source: "b"}}}
+	unused := &fakeSource{name: "unused"}
+
+	r := NewRegistry(zap.NewNop())
+	for _, s := range []*fakeSource{a, b, unused} {
+		s := s
+		require.NoError(t, r.Register(s.name, func() (SignalSource, error) { return s, nil }))
+	}
+
+	assert.ErrorIs(t, r.Register("a", nil), errDuplicateSource)
+	assert.Equal(t, []string{"a", "b", "unused"}, r.Names())
+
+	ch, err := r.Start("a", "b")
+	require.NoError(t, err)
+
+	_, err = r.Start("a")
+	assert.ErrorIs(t, err, errAlreadyStarted)
+
+	symbols := []string{}
+	for i := 0; i < 3; i++ {
+		symbols = append(symbols, (<-ch).Symbol)
+	}
+
+	sort.Strings(symbols)
+	assert.Equal(t, []string{"DOT", "ETH", "SOL"}, symbols)
+
+	r.Stop()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.True(t, a.stopped)
+	assert.True(t, b.stopped)
+	assert.False(t, unused.stopped)
+}
+
+func TestRegistryStartFailure(t *testing.T) {
+	a := &fakeSource{name: "a"}
+	b := &fakeSource{name: "b", startErr: errStartFailed}
+
+	r := NewRegistry(zap.NewNop())
+	require.NoError(t, r.Register("a", func() (SignalSource, error) { return a, nil }))
+	require.NoError(t, r.Register("b", func() (SignalSource, error) { return b, nil }))
+
+	_, err := r.Start("a", "b")
+	assert.ErrorIs(t, err, errStartFailed)
+	assert.True(t, a.stopped)
+
+	_, err = r.Start("c")
+	assert.ErrorIs(t, err, errUnknownSource)
+}
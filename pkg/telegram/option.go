@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBaseURL     = "https://api.telegram.org"
+	defaultPollTimeout = 30 * time.Second
+	defaultRetryDelay  = 5 * time.Second
+	// the Bot API keeps unconfirmed updates for 24 hours, they are replayed after a restart
+	defaultMaxMessageAge = 5 * time.Minute
+)
+
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	baseURL     string
+	httpClient  *http.Client
+	pollTimeout time.Duration
+	retryDelay  time.Duration
+	logger      *zap.Logger
+
+	maxMessageAge time.Duration
+}
+
+func newDefaultOptions() options {
+	return options{
+		baseURL:     defaultBaseURL,
+		httpClient:  &http.Client{},
+		pollTimeout: defaultPollTimeout,
+		retryDelay:  defaultRetryDelay,
+		logger:      zap.NewNop(),
+
+		maxMessageAge: defaultMaxMessageAge,
+	}
+}
+
+type baseURLOption string
+
+func (c baseURLOption) apply(opts *options) {
+	opts.baseURL = string(c)
+}
+
+func WithBaseURL(baseURL string) Option {
+	return baseURLOption(baseURL)
+}
+
+type httpClientOption struct {
+	httpClient *http.Client
+}
+
+func (c httpClientOption) apply(opts *options) {
+	opts.httpClient = c.httpClient
+}
+
+// WithHTTPClient sets the client of the requests, its timeout has to be longer than the poll timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return httpClientOption{httpClient: httpClient}
+}
+
+type pollTimeoutOption time.Duration
+
+func (c pollTimeoutOption) apply(opts *options) {
+	opts.pollTimeout = time.Duration(c)
+}
+
+// WithPollTimeout sets how long a getUpdates request waits for an update before returning empty.
+func WithPollTimeout(d time.Duration) Option {
+	return pollTimeoutOption(d)
+}
+
+type retryDelayOption time.Duration
+
+func (c retryDelayOption) apply(opts *options) {
+	opts.retryDelay = time.Duration(c)
+}
+
+// WithRetryDelay sets the wait after a failed getUpdates request.
+func WithRetryDelay(d time.Duration) Option {
+	return retryDelayOption(d)
+}
+
+type maxMessageAgeOption time.Duration
+
+func (c maxMessageAgeOption) apply(opts *options) {
+	opts.maxMessageAge = time.Duration(c)
+}
+
+// WithMaxMessageAge drops the messages sent longer ago than the duration, so that the updates replayed by
+// the Bot API after a restart do not produce signals. Zero keeps every message.
+func WithMaxMessageAge(d time.Duration) Option {
+	return maxMessageAgeOption(d)
+}
+
+type loggerOption struct {
+	logger *zap.Logger
+}
+
+func (c loggerOption) apply(opts *options) {
+	opts.logger = c.logger
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return loggerOption{logger: logger}
+}
@@ -0,0 +1,256 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/lht102/ctrade/pkg/tweet"
+	"go.uber.org/zap"
+)
+
+const SourceName = "telegram"
+
+var (
+	errUnexpectedStatusCode = errors.New("unexpected status code")
+	errBotAPI               = errors.New("telegram bot api error")
+)
+
+type chat struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Title    string `json:"title"`
+}
+
+type message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+	Caption   string `json:"caption"`
+}
+
+type update struct {
+	UpdateID    int64    `json:"update_id"`
+	Message     *message `json:"message"`
+	ChannelPost *message `json:"channel_post"`
+}
+
+type getUpdatesResponse struct {
+	OK          bool     `json:"ok"`
+	Description string   `json:"description"`
+	Result      []update `json:"result"`
+}
+
+// Source long polls the Telegram Bot API for the messages of the chats the bot is in, and emits a signal
+// for every supported symbol of a message matching the pattern library. The accounts of a pattern are
+// the chat usernames without "@" or the chat ids. Messages older than the max message age are dropped.
+type Source struct {
+	botToken       string
+	patternLibrary *tweet.PatternLibrary
+	supportedCoins coin.Set
+	opts           options
+
+	offset int64
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewSource(
+	botToken string,
+	patternLibrary *tweet.PatternLibrary,
+	supportedCoins coin.Set,
+	opts ...Option,
+) *Source {
+	options := newDefaultOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &Source{
+		botToken:       botToken,
+		patternLibrary: patternLibrary,
+		supportedCoins: supportedCoins,
+		opts:           options,
+	}
+}
+
+func (s *Source) Name() string {
+	return SourceName
+}
+
+func (s *Source) Start() (<-chan api.Signal, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	signalCh := make(chan api.Signal)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer close(signalCh)
+
+		s.run(ctx, signalCh)
+	}()
+
+	return signalCh, nil
+}
+
+func (s *Source) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	s.wg.Wait()
+}
+
+func (s *Source) run(ctx context.Context, signalCh chan<- api.Signal) {
+	for ctx.Err() == nil {
+		updates, err := s.getUpdates(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			s.opts.logger.Error("Fail to get telegram updates", zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.opts.retryDelay):
+			}
+
+			continue
+		}
+
+		for _, u := range updates {
+			s.offset = u.UpdateID + 1
+
+			for _, v := range s.handleUpdate(u) {
+				select {
+				case <-ctx.Done():
+					return
+				case signalCh <- v:
+				}
+			}
+		}
+	}
+}
+
+func (s *Source) handleUpdate(u update) []api.Signal {
+	msg := u.ChannelPost
+	if msg == nil {
+		msg = u.Message
+	}
+
+	if msg == nil {
+		return nil
+	}
+
+	sentAt := time.Unix(msg.Date, 0)
+	if s.opts.maxMessageAge > 0 && time.Since(sentAt) > s.opts.maxMessageAge {
+		s.opts.logger.Info(
+			"Skip stale telegram message",
+			zap.Int64("updateID", u.UpdateID),
+			zap.String("source", getMessageURL(msg)),
+			zap.Time("sentAt", sentAt),
+		)
+
+		return nil
+	}
+
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+
+	p, similarity := s.patternLibrary.Match(strconv.FormatInt(msg.Chat.ID, 10), text)
+	if p == nil && msg.Chat.Username != "" {
+		p, similarity = s.patternLibrary.Match(msg.Chat.Username, text)
+	}
+
+	if p == nil {
+		return nil
+	}
+
+	res := []api.Signal{}
+	detectedAt := time.Now()
+
+	for _, symbol := range p.ExtractSymbols(text) {
+		if !s.supportedCoins.Contains(symbol) {
+			continue
+		}
+
+		signal := api.NewSignal(api.BuySignal{
+			Symbol: symbol,
+			Source: getMessageURL(msg),
+			Stage:  p.Stage,
+			Side:   p.Side,
+		}, api.SourceKindTelegram, detectedAt)
+		signal.EventAt = sentAt
+		signal.Confidence = similarity
+		signal.RawText = text
+		signal.Metadata = map[string]string{"pattern": p.Name, "chat": strconv.FormatInt(msg.Chat.ID, 10)}
+
+		res = append(res, signal)
+	}
+
+	return res
+}
+
+func (s *Source) getUpdates(ctx context.Context) ([]update, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.opts.baseURL+"/bot"+s.botToken+"/getUpdates", nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("offset", strconv.FormatInt(s.offset, 10))
+	q.Set("timeout", strconv.Itoa(int(s.opts.pollTimeout.Seconds())))
+	q.Set("allowed_updates", `["message","channel_post"]`)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := s.opts.httpClient.Do(req)
+	if err != nil {
+		// the url holds the bot token, it must not end up in the logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return nil, fmt.Errorf("get updates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body getUpdatesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("get updates: %w %d", errUnexpectedStatusCode, resp.StatusCode)
+		}
+
+		return nil, fmt.Errorf("decode updates: %w", err)
+	}
+
+	if !body.OK {
+		return nil, fmt.Errorf("get updates: %w: %s", errBotAPI, body.Description)
+	}
+
+	return body.Result, nil
+}
+
+func getMessageURL(msg *message) string {
+	if msg.Chat.Username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", msg.Chat.Username, msg.MessageID)
+	}
+
+	return fmt.Sprintf("telegram:%d/%d", msg.Chat.ID, msg.MessageID)
+}
@@ -0,0 +1,181 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/lht102/ctrade/pkg/tweet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBotToken = "123:abc"
+
+// fakeBotAPI serves getUpdates from a fixed list of updates, honouring the offset like the Bot API.
+type fakeBotAPI struct {
+	t       *testing.T
+	mu      sync.Mutex
+	updates []update
+	offsets []int64
+	fail    bool
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/bot"+testBotToken+"/getUpdates" {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(getUpdatesResponse{OK: false, Description: "Not Found"})
+
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	require.NoError(f.t, err)
+
+	f.mu.Lock()
+	f.offsets = append(f.offsets, offset)
+
+	if f.fail {
+		f.fail = false
+		f.mu.Unlock()
+
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(getUpdatesResponse{OK: false, Description: "Conflict: terminated by other getUpdates request"})
+
+		return
+	}
+
+	res := []update{}
+
+	for _, u := range f.updates {
+		if u.UpdateID >= offset {
+			res = append(res, u)
+		}
+	}
+	f.mu.Unlock()
+
+	if len(res) == 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	_ = json.NewEncoder(w).Encode(getUpdatesResponse{OK: true, Result: res})
+}
+
+func TestSource(t *testing.T) {
+	library, err := tweet.NewPatternLibrary(
+		tweet.Pattern{
+			Name:      "listing_leak",
+			Accounts:  []string{"listings"},
+			Templates: []string{"Binance will list $XXX"},
+			Keywords:  []string{"will list"},
+			Extraction: tweet.ExtractionRule{
+				Type:  tweet.ExtractionRegex,
+				Regex: `\$([A-Z0-9]+)`,
+			},
+		},
+		tweet.Pattern{
+			Name:      "delisting_leak",
+			Accounts:  []string{"-1001"},
+			Templates: []string{"Binance will delist $XXX"},
+			Keywords:  []string{"delist"},
+			Extraction: tweet.ExtractionRule{
+				Type:  tweet.ExtractionRegex,
+				Regex: `\$([A-Z0-9]+)`,
+			},
+			Side: api.SignalSideSell,
+		},
+	)
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	stale := time.Now().Add(-time.Hour).Unix()
+
+	fake := &fakeBotAPI{
+		t:    t,
+		fail: true,
+		updates: []update{
+			{UpdateID: 9, ChannelPost: &message{MessageID: 9, Chat: chat{ID: -1002, Username: "listings"}, Date: stale, Text: "Binance will list $ETH"}},
+			{UpdateID: 10, ChannelPost: &message{MessageID: 1, Chat: chat{ID: -1002, Username: "listings"}, Date: now, Text: "Binance will list $SOL"}},
+			{UpdateID: 11, ChannelPost: &message{MessageID: 2, Chat: chat{ID: -1002, Username: "listings"}, Date: now, Text: "Binance will list $NOTSUPPORTED"}},
+			{UpdateID: 12, Message: &message{MessageID: 3, Chat: chat{ID: 5, Username: "someone"}, Date: now, Text: "Binance will list $ETH"}},
+			{UpdateID: 13, ChannelPost: &message{MessageID: 4, Chat: chat{ID: -1001}, Date: now, Caption: "Binance will delist $ETH"}},
+			{UpdateID: 14, ChannelPost: &message{MessageID: 5, Chat: chat{ID: -1002, Username: "listings"}, Date: now, Text: "gm"}},
+		},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := NewSource(
+		testBotToken,
+		library,
+		coin.Symbols{"SOL": {}, "ETH": {}},
+		WithBaseURL(server.URL),
+		WithRetryDelay(time.Millisecond),
+		WithPollTimeout(time.Second),
+	)
+
+	ch, err := s.Start()
+	require.NoError(t, err)
+
+	signals := []api.BuySignal{}
+
+	for len(signals) < 2 {
+		select {
+		case v := <-ch:
+			assert.Equal(t, api.SourceKindTelegram, v.SourceKind)
+			assert.Greater(t, v.Confidence, 0.5, "the confidence is the similarity to the pattern")
+			assert.LessOrEqual(t, v.Confidence, 1.0)
+			assert.NotEmpty(t, v.RawText)
+			signals = append(signals, v.BuySignal())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for signals")
+		}
+	}
+
+	assert.Equal(t, []api.BuySignal{
+		{Symbol: "SOL", Source: "https://t.me/listings/1", Side: api.SignalSideBuy},
+		{Symbol: "ETH", Source: "telegram:-1001/4", Side: api.SignalSideSell},
+	}, signals, "the stale message replayed after a restart is dropped")
+
+	assert.Eventually(t, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		return fake.offsets[len(fake.offsets)-1] == 15
+	}, 5*time.Second, 10*time.Millisecond)
+
+	s.Stop()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	assert.Equal(t, []int64{0, 0}, fake.offsets[:2], "the failed request is retried with the same offset")
+}
+
+func TestGetUpdatesErrorWithoutBotToken(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	library, err := tweet.NewPatternLibrary()
+	require.NoError(t, err)
+
+	s := NewSource(testBotToken, library, coin.Symbols{}, WithBaseURL(server.URL))
+
+	_, err = s.getUpdates(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), testBotToken)
+}
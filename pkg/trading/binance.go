@@ -0,0 +1,337 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	incomeTypeRealizedPnL = "REALIZED_PNL"
+	maxIncomeHistoryLimit = 1000
+)
+
+type BinanceFuturesExchange struct {
+	futuresClient *futures.Client
+}
+
+func NewBinanceFuturesExchange(futuresClient *futures.Client) *BinanceFuturesExchange {
+	return &BinanceFuturesExchange{
+		futuresClient: futuresClient,
+	}
+}
+
+func (e *BinanceFuturesExchange) GetSymbolsInfo(ctx context.Context) (map[string]futures.Symbol, error) {
+	resp, err := e.futuresClient.
+		NewExchangeInfoService().
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get exchange info: %w", err)
+	}
+
+	res := make(map[string]futures.Symbol, len(resp.Symbols))
+	for _, s := range resp.Symbols {
+		res[s.Symbol] = s
+	}
+
+	return res, nil
+}
+
+func (e *BinanceFuturesExchange) GetPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	res, err := e.futuresClient.NewListPricesService().
+		Symbol(symbol).
+		Do(ctx)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("binance futures list prices: %w", err)
+	}
+
+	if len(res) == 0 {
+		return decimal.Decimal{}, errEmptyPriceList
+	}
+
+	p, err := decimal.NewFromString(res[0].Price)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("convert price string to decimal: %w", err)
+	}
+
+	return p, nil
+}
+
+func (e *BinanceFuturesExchange) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	_, err := e.futuresClient.NewChangeLeverageService().
+		Symbol(symbol).
+		Leverage(leverage).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("change leverage: %w", err)
+	}
+
+	return nil
+}
+
+func (e *BinanceFuturesExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	s := e.futuresClient.NewCreateOrderService().
+		Symbol(req.Symbol).
+		Side(req.Side).
+		Type(req.Type)
+
+	if req.TimeInForce != "" {
+		s = s.TimeInForce(req.TimeInForce)
+	}
+
+	if !req.Quantity.IsZero() {
+		s = s.Quantity(req.Quantity.String())
+	}
+
+	if !req.Price.IsZero() {
+		s = s.Price(req.Price.String())
+	}
+
+	if !req.StopPrice.IsZero() {
+		s = s.StopPrice(req.StopPrice.String())
+	}
+
+	if !req.ActivationPrice.IsZero() {
+		s = s.ActivationPrice(req.ActivationPrice.String())
+	}
+
+	if !req.CallbackRate.IsZero() {
+		s = s.CallbackRate(req.CallbackRate.String())
+	}
+
+	if req.ReduceOnly {
+		s = s.ReduceOnly(true)
+	}
+
+	if req.ClosePosition {
+		s = s.ClosePosition(true)
+	}
+
+	resp, err := s.Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("binance futures create order: %w", err)
+	}
+
+	o, err := toOrder(&futures.Order{
+		Symbol:           resp.Symbol,
+		OrderID:          resp.OrderID,
+		Price:            resp.Price,
+		ReduceOnly:       resp.ReduceOnly,
+		OrigQuantity:     resp.OrigQuantity,
+		ExecutedQuantity: resp.ExecutedQuantity,
+		Status:           resp.Status,
+		Type:             resp.Type,
+		Side:             resp.Side,
+		StopPrice:        resp.StopPrice,
+		AvgPrice:         resp.AvgPrice,
+		ActivatePrice:    resp.ActivatePrice,
+		PriceRate:        resp.PriceRate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// futures.Order has no closePosition, only the response of the created order does
+	o.ClosePosition = resp.ClosePosition
+
+	return o, nil
+}
+
+func (e *BinanceFuturesExchange) CancelOrder(ctx context.Context, symbol string, orderID int64) error {
+	_, err := e.futuresClient.NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("binance futures cancel order: %w", err)
+	}
+
+	return nil
+}
+
+func (e *BinanceFuturesExchange) GetOrder(ctx context.Context, symbol string, orderID int64) (*Order, error) {
+	resp, err := e.futuresClient.NewGetOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("binance futures get order: %w", err)
+	}
+
+	return toOrder(resp)
+}
+
+func (e *BinanceFuturesExchange) ListOpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	resp, err := e.futuresClient.NewListOpenOrdersService().
+		Symbol(symbol).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("binance futures list open orders: %w", err)
+	}
+
+	res := make([]*Order, 0, len(resp))
+
+	for _, o := range resp {
+		order, err := toOrder(o)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, order)
+	}
+
+	return res, nil
+}
+
+func (e *BinanceFuturesExchange) GetPositions(ctx context.Context) ([]*Position, error) {
+	resp, err := e.futuresClient.NewGetPositionRiskService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("binance futures get position risk: %w", err)
+	}
+
+	res := []*Position{}
+
+	for _, p := range resp {
+		amt, err := parseDecimal(p.PositionAmt)
+		if err != nil {
+			return nil, err
+		}
+
+		if amt.IsZero() {
+			continue
+		}
+
+		entryPrice, err := parseDecimal(p.EntryPrice)
+		if err != nil {
+			return nil, err
+		}
+
+		markPrice, err := parseDecimal(p.MarkPrice)
+		if err != nil {
+			return nil, err
+		}
+
+		unrealizedProfit, err := parseDecimal(p.UnRealizedProfit)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &Position{
+			Symbol:           p.Symbol,
+			Amount:           amt,
+			EntryPrice:       entryPrice,
+			MarkPrice:        markPrice,
+			UnrealizedProfit: unrealizedProfit,
+		})
+	}
+
+	return res, nil
+}
+
+func (e *BinanceFuturesExchange) GetAvailableBalance(ctx context.Context, asset string) (decimal.Decimal, error) {
+	resp, err := e.futuresClient.NewGetBalanceService().Do(ctx)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("binance futures get balance: %w", err)
+	}
+
+	for _, b := range resp {
+		if b.Asset == asset {
+			return parseDecimal(b.AvailableBalance)
+		}
+	}
+
+	return decimal.Zero, nil
+}
+
+func (e *BinanceFuturesExchange) GetRealizedPnL(ctx context.Context, since time.Time) (decimal.Decimal, error) {
+	resp, err := e.futuresClient.NewGetIncomeHistoryService().
+		IncomeType(incomeTypeRealizedPnL).
+		StartTime(since.UnixNano() / int64(time.Millisecond)).
+		Limit(maxIncomeHistoryLimit).
+		Do(ctx)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("binance futures get income history: %w", err)
+	}
+
+	res := decimal.Zero
+
+	for _, income := range resp {
+		pnl, err := parseDecimal(income.Income)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+
+		res = res.Add(pnl)
+	}
+
+	return res, nil
+}
+
+func toOrder(o *futures.Order) (*Order, error) {
+	price, err := parseDecimal(o.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	stopPrice, err := parseDecimal(o.StopPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	origQty, err := parseDecimal(o.OrigQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	executedQty, err := parseDecimal(o.ExecutedQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	avgPrice, err := parseDecimal(o.AvgPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	activationPrice, err := parseDecimal(o.ActivatePrice)
+	if err != nil {
+		return nil, err
+	}
+
+	callbackRate, err := parseDecimal(o.PriceRate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		Symbol:           o.Symbol,
+		OrderID:          o.OrderID,
+		Side:             o.Side,
+		Type:             o.Type,
+		Status:           o.Status,
+		Price:            price,
+		StopPrice:        stopPrice,
+		OrigQuantity:     origQty,
+		ExecutedQuantity: executedQty,
+		AvgPrice:         avgPrice,
+		ActivationPrice:  activationPrice,
+		CallbackRate:     callbackRate,
+		ReduceOnly:       o.ReduceOnly,
+	}, nil
+}
+
+func parseDecimal(s string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.Zero, nil
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("convert string to decimal: %w", err)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,232 @@
+package trading
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/pkg/journal"
+	"go.uber.org/zap"
+)
+
+// DeadlineStore persists the time by which each position has to be closed.
+type DeadlineStore interface {
+	SaveDeadline(symbol string, deadline time.Time) error
+	DeleteDeadline(symbol string) error
+	LoadDeadlines() (map[string]time.Time, error)
+}
+
+// FileDeadlineStore keeps the deadlines in a JSON file.
+type FileDeadlineStore struct {
+	path string
+
+	mu sync.Mutex
+}
+
+func NewFileDeadlineStore(path string) *FileDeadlineStore {
+	return &FileDeadlineStore{
+		path: path,
+	}
+}
+
+func (s *FileDeadlineStore) SaveDeadline(symbol string, deadline time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadlines, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	deadlines[symbol] = deadline
+
+	return s.write(deadlines)
+}
+
+func (s *FileDeadlineStore) DeleteDeadline(symbol string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadlines, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	delete(deadlines, symbol)
+
+	return s.write(deadlines)
+}
+
+func (s *FileDeadlineStore) LoadDeadlines() (map[string]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+func (s *FileDeadlineStore) read() (map[string]time.Time, error) {
+	deadlines := make(map[string]time.Time)
+
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return deadlines, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read deadline file: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &deadlines); err != nil {
+		return nil, fmt.Errorf("unmarshal deadline file: %w", err)
+	}
+
+	return deadlines, nil
+}
+
+func (s *FileDeadlineStore) write(deadlines map[string]time.Time) error {
+	b, err := json.Marshal(deadlines)
+	if err != nil {
+		return fmt.Errorf("marshal deadlines: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o600); err != nil { // nolint: gomnd
+		return fmt.Errorf("write deadline file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("replace deadline file: %w", err)
+	}
+
+	return nil
+}
+
+func (m *BinanceFuturesManager) scheduleExit(symbol string) error {
+	if m.futuresOpts.maxHoldingDuration <= 0 {
+		return nil
+	}
+
+	deadline := m.now().Add(m.futuresOpts.maxHoldingDuration)
+
+	m.mu.Lock()
+	m.deadlines[symbol] = deadline
+	m.mu.Unlock()
+
+	if m.futuresOpts.deadlineStore != nil {
+		if err := m.futuresOpts.deadlineStore.SaveDeadline(symbol, deadline); err != nil {
+			return fmt.Errorf("save position deadline: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CheckPositionDeadlines closes every position that has been held longer than the max holding duration,
+// or only logs it if orders are not executed.
+func (m *BinanceFuturesManager) CheckPositionDeadlines() error {
+	ctx := context.Background()
+	now := m.now()
+
+	for symbol, deadline := range m.getDeadlines() {
+		if now.Before(deadline) {
+			continue
+		}
+
+		m.logger.Info("Position reached max holding duration", zap.String("symbol", symbol), zap.Time("deadline", deadline))
+
+		if !m.futuresOpts.willExecuteOrder {
+			// the stored deadline is kept for when orders are executed again
+			m.mu.Lock()
+			delete(m.deadlines, symbol)
+			m.mu.Unlock()
+
+			continue
+		}
+
+		if err := m.closePosition(ctx, symbol); err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		delete(m.deadlines, symbol)
+		m.mu.Unlock()
+
+		if m.futuresOpts.deadlineStore != nil {
+			if err := m.futuresOpts.deadlineStore.DeleteDeadline(symbol); err != nil {
+				return fmt.Errorf("delete position deadline: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// closePosition cancels the open orders of the symbol and closes the position with a reduce only market order.
+func (m *BinanceFuturesManager) closePosition(ctx context.Context, symbol string) error {
+	openOrders, err := m.exchange.ListOpenOrders(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("list open orders: %w", err)
+	}
+
+	for _, o := range openOrders {
+		if err := m.cancelOrder(ctx, journal.OrderKindExit, symbol, o.OrderID); err != nil {
+			return fmt.Errorf("cancel open order: %w", err)
+		}
+	}
+
+	m.untrackExitOrders(symbol)
+
+	positions, err := m.getPositionAmounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	amt := positions[symbol]
+	if amt.IsZero() {
+		return nil
+	}
+
+	side := futures.SideTypeSell
+	if amt.IsNegative() {
+		side = futures.SideTypeBuy
+	}
+
+	closeOrder, err := m.createOrder(ctx, journal.OrderKindExit, OrderRequest{
+		Symbol:     symbol,
+		Side:       side,
+		Type:       futures.OrderTypeMarket,
+		Quantity:   amt.Abs(),
+		ReduceOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("create close position order: %w", err)
+	}
+
+	closeOrder, err = m.exchange.GetOrder(ctx, symbol, closeOrder.OrderID)
+	if err != nil {
+		return fmt.Errorf("get close position order: %w", err)
+	}
+
+	m.recordOrder(journal.OrderKindExit, closeOrder)
+
+	m.logger.Sugar().Infof("Closed %s position of %s amount", symbol, amt.String())
+
+	return nil
+}
+
+func (m *BinanceFuturesManager) getDeadlines() map[string]time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[string]time.Time, len(m.deadlines))
+	for symbol, deadline := range m.deadlines {
+		res[symbol] = deadline
+	}
+
+	return res
+}
@@ -0,0 +1,112 @@
+package trading
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFileDeadlineStore(t *testing.T) {
+	store := NewFileDeadlineStore(filepath.Join(t.TempDir(), "deadlines.json"))
+
+	deadlines, err := store.LoadDeadlines()
+	require.NoError(t, err)
+	assert.Empty(t, deadlines)
+
+	deadline := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+	require.NoError(t, store.SaveDeadline("SHIBUSDT", deadline))
+	require.NoError(t, store.SaveDeadline("CHZUSDT", deadline))
+	require.NoError(t, store.DeleteDeadline("CHZUSDT"))
+
+	deadlines, err = store.LoadDeadlines()
+	require.NoError(t, err)
+	require.Len(t, deadlines, 1)
+	assert.True(t, deadline.Equal(deadlines["SHIBUSDT"]))
+}
+
+func TestCheckPositionDeadlinesAfterRestart(t *testing.T) {
+	store := NewFileDeadlineStore(filepath.Join(t.TempDir(), "deadlines.json"))
+	marketData := newFakeExchange(decimal.NewFromInt(40))
+	paper := NewPaperExchange(marketData, zap.NewNop(), WithPaperSlippagePercentage(0))
+	opts := []FuturesOption{
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithStopLossPriceChangedPercentage(5),
+		WithMaxHoldingDuration(4 * time.Hour),
+		WithDeadlineStore(store),
+	}
+	now := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	m, err := NewBinanceFuturesManager(paper, zap.NewNop(), opts...)
+	require.NoError(t, err)
+
+	m.now = func() time.Time { return now }
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	restarted, err := NewBinanceFuturesManager(paper, zap.NewNop(), opts...)
+	require.NoError(t, err)
+
+	restarted.now = func() time.Time { return now.Add(3 * time.Hour) }
+	require.NoError(t, restarted.CheckPositionDeadlines())
+
+	positions, err := paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, positions, 1)
+
+	restarted.now = func() time.Time { return now.Add(4 * time.Hour) }
+	marketData.price = decimal.NewFromInt(41)
+	require.NoError(t, restarted.CheckPositionDeadlines())
+
+	positions, err = paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+
+	openOrders, err := paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders)
+	assert.Equal(t, "2.5", paper.PnL().RealizedPnL.String())
+
+	deadlines, err := store.LoadDeadlines()
+	require.NoError(t, err)
+	assert.Empty(t, deadlines)
+}
+
+func TestCheckPositionDeadlinesDryRun(t *testing.T) {
+	store := NewFileDeadlineStore(filepath.Join(t.TempDir(), "deadlines.json"))
+	paper := NewPaperExchange(newFakeExchange(decimal.NewFromInt(40)), zap.NewNop(), WithPaperSlippagePercentage(0))
+	now := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithMaxHoldingDuration(4*time.Hour),
+		WithDeadlineStore(store),
+	)
+	require.NoError(t, err)
+
+	m.now = func() time.Time { return now }
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	dryRun, err := NewBinanceFuturesManager(paper, zap.NewNop(), WithMaxHoldingDuration(4*time.Hour), WithDeadlineStore(store))
+	require.NoError(t, err)
+
+	dryRun.now = func() time.Time { return now.Add(5 * time.Hour) }
+	require.NoError(t, dryRun.CheckPositionDeadlines())
+
+	positions, err := paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, positions, 1)
+	assert.Empty(t, dryRun.getDeadlines())
+
+	deadlines, err := store.LoadDeadlines()
+	require.NoError(t, err)
+	assert.Len(t, deadlines, 1)
+}
@@ -0,0 +1,247 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/pkg/journal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	errUnknownEntryMode         = errors.New("unknown entry mode")
+	errPostOnlyEntryAboveMarket = errors.New("post only entry order with a positive max slippage would fill at once")
+)
+
+// EntryMode decides the type of the order opening a position.
+type EntryMode string
+
+const (
+	// EntryModeMarket takes whatever the order book offers.
+	EntryModeMarket EntryMode = "market"
+	// EntryModeLimitIOC sends an immediate or cancel limit order at the price plus the max slippage, the
+	// part that cannot be filled at once is cancelled.
+	EntryModeLimitIOC EntryMode = "limit_ioc"
+	// EntryModeLimitGTX sends a post only limit order at the price plus the max slippage, it waits in the order
+	// book for the requote interval and the remainder is cancelled. It is rejected if it would fill at once, so
+	// the max slippage has to be zero or negative.
+	EntryModeLimitGTX EntryMode = "limit_gtx"
+)
+
+func ParseEntryMode(s string) (EntryMode, error) {
+	switch mode := EntryMode(s); mode {
+	case EntryModeMarket, EntryModeLimitIOC, EntryModeLimitGTX:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %s", errUnknownEntryMode, s)
+	}
+}
+
+func (mode EntryMode) timeInForce() futures.TimeInForceType {
+	if mode == EntryModeLimitGTX {
+		return futures.TimeInForceTypeGTX
+	}
+
+	return futures.TimeInForceTypeIOC
+}
+
+// placeEntryOrder opens the position with the quantity by the entry mode, the returned order sums up the fills of
+// every order sent and is nil if nothing is filled.
+func (m *BinanceFuturesManager) placeEntryOrder(
+	ctx context.Context,
+	futuresSymbol futures.Symbol,
+	side futures.SideType,
+	price decimal.Decimal,
+	qty decimal.Decimal,
+) (*Order, error) {
+	if m.futuresOpts.entryMode == EntryModeMarket {
+		return m.placeMarketEntryOrder(ctx, futuresSymbol.Symbol, side, price, qty)
+	}
+
+	return m.placeLimitEntryOrder(ctx, futuresSymbol, side, price, qty)
+}
+
+func (m *BinanceFuturesManager) placeMarketEntryOrder(
+	ctx context.Context,
+	symbol string,
+	side futures.SideType,
+	price decimal.Decimal,
+	qty decimal.Decimal,
+) (*Order, error) {
+	createOrderResp, err := m.createOrder(ctx, journal.OrderKindEntry, OrderRequest{
+		Symbol:   symbol,
+		Side:     side,
+		Type:     futures.OrderTypeMarket,
+		Quantity: qty,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s order: %w", strings.ToLower(string(side)), err)
+	}
+	m.logger.Sugar().Infof("Executed a %s %s order at ~%s with %s amount", symbol, strings.ToLower(string(side)), price.String(), qty.String())
+
+	getOrderResp, err := m.exchange.GetOrder(ctx, symbol, createOrderResp.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+
+	m.recordOrder(journal.OrderKindEntry, getOrderResp)
+
+	return getOrderResp, nil
+}
+
+// placeLimitEntryOrder sends limit orders capped at the price plus the max slippage, and requotes the remainder
+// at the latest price until it is filled or the requotes run out. The unfilled remainder is reported and dropped.
+func (m *BinanceFuturesManager) placeLimitEntryOrder(
+	ctx context.Context,
+	futuresSymbol futures.Symbol,
+	side futures.SideType,
+	price decimal.Decimal,
+	qty decimal.Decimal,
+) (*Order, error) {
+	symbol := futuresSymbol.Symbol
+
+	tickSize, err := decimal.NewFromString(futuresSymbol.PriceFilter().TickSize)
+	if err != nil {
+		return nil, fmt.Errorf("convert tick size string to decimal: %w", err)
+	}
+
+	var lastOrder *Order
+
+	remaining := qty
+	filledQty := decimal.Zero
+	filledNotional := decimal.Zero
+
+	for attempt := 0; attempt <= m.futuresOpts.entryRequotes; attempt++ {
+		if attempt > 0 {
+			if price, err = m.exchange.GetPrice(ctx, symbol); err != nil {
+				m.logger.Error("Fail to get price to requote entry order", zap.String("symbol", symbol), zap.Error(err))
+
+				break
+			}
+
+			if _, err := clampQuantity(futuresSymbol, remaining, price); err != nil {
+				m.logger.Info("Skip requoting entry order", zap.String("symbol", symbol), zap.Error(err))
+
+				break
+			}
+		}
+
+		o, err := m.sendLimitEntryOrder(ctx, symbol, side, limitPriceOf(price, side, m.futuresOpts.entryMaxSlippagePercentage, tickSize), remaining)
+		if err != nil {
+			if filledQty.IsZero() {
+				return nil, err
+			}
+
+			m.logger.Error("Fail to requote entry order", zap.String("symbol", symbol), zap.Error(err))
+
+			break
+		}
+
+		lastOrder = o
+		filledQty = filledQty.Add(o.ExecutedQuantity)
+		filledNotional = filledNotional.Add(o.ExecutedQuantity.Mul(o.AvgPrice))
+		remaining = qty.Sub(filledQty)
+
+		if !remaining.IsPositive() {
+			break
+		}
+	}
+
+	if remaining.IsPositive() {
+		m.logger.Warn(
+			"Entry order not fully filled",
+			zap.String("symbol", symbol),
+			zap.String("side", string(side)),
+			zap.String("quantity", qty.String()),
+			zap.String("filled", filledQty.String()),
+			zap.String("unfilled", remaining.String()),
+		)
+	}
+
+	if filledQty.IsZero() {
+		return nil, nil
+	}
+
+	entryOrder := *lastOrder
+	entryOrder.OrigQuantity = qty
+	entryOrder.ExecutedQuantity = filledQty
+	entryOrder.AvgPrice = filledNotional.Div(filledQty)
+
+	return &entryOrder, nil
+}
+
+// sendLimitEntryOrder sends a limit order and returns it once it is done, a post only order left in the order
+// book is cancelled after the requote interval.
+func (m *BinanceFuturesManager) sendLimitEntryOrder(
+	ctx context.Context,
+	symbol string,
+	side futures.SideType,
+	limitPrice decimal.Decimal,
+	qty decimal.Decimal,
+) (*Order, error) {
+	createOrderResp, err := m.createOrder(ctx, journal.OrderKindEntry, OrderRequest{
+		Symbol:      symbol,
+		Side:        side,
+		Type:        futures.OrderTypeLimit,
+		TimeInForce: m.futuresOpts.entryMode.timeInForce(),
+		Quantity:    qty,
+		Price:       limitPrice,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s limit order: %w", strings.ToLower(string(side)), err)
+	}
+	o, err := m.exchange.GetOrder(ctx, symbol, createOrderResp.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+
+	if isOrderOpen(o) {
+		select {
+		case <-ctx.Done():
+			// the order must not be left in the order book
+			ctx = context.Background()
+		case <-time.After(m.futuresOpts.entryRequoteInterval):
+		}
+
+		if err := m.cancelOrder(ctx, journal.OrderKindEntry, symbol, o.OrderID); err != nil {
+			m.logger.Error("Fail to cancel entry order", zap.String("symbol", symbol), zap.Int64("orderID", o.OrderID), zap.Error(err))
+		}
+
+		if o, err = m.exchange.GetOrder(ctx, symbol, createOrderResp.OrderID); err != nil {
+			return nil, fmt.Errorf("get order: %w", err)
+		}
+	}
+
+	m.recordOrder(journal.OrderKindEntry, o)
+
+	if o.ExecutedQuantity.IsZero() {
+		m.logger.Sugar().Infof("A %s %s limit order at %s with %s amount expired unfilled", symbol, strings.ToLower(string(side)), limitPrice.String(), qty.String())
+	} else {
+		m.logger.Sugar().Infof(
+			"Executed a %s %s limit order at %s with %s of %s amount filled at %s",
+			symbol, strings.ToLower(string(side)), limitPrice.String(), o.ExecutedQuantity.String(), qty.String(), o.AvgPrice.String(),
+		)
+	}
+
+	return o, nil
+}
+
+// limitPriceOf returns the worst acceptable price of the side, rounded to the tick size towards the price.
+func limitPriceOf(price decimal.Decimal, side futures.SideType, maxSlippagePercentage float64, tickSize decimal.Decimal) decimal.Decimal {
+	slippage := decimal.NewFromFloat(maxSlippagePercentage).Div(decimal.NewFromInt(100)) // nolint: gomnd
+
+	if side == futures.SideTypeSell {
+		return price.Mul(decimal.NewFromInt(1).Sub(slippage)).Div(tickSize).Ceil().Mul(tickSize)
+	}
+
+	return price.Mul(decimal.NewFromInt(1).Add(slippage)).Div(tickSize).Floor().Mul(tickSize)
+}
+
+func isOrderOpen(o *Order) bool {
+	return o.Status == futures.OrderStatusTypeNew || o.Status == futures.OrderStatusTypePartiallyFilled
+}
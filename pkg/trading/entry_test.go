@@ -0,0 +1,214 @@
+package trading
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLimitPriceOf(t *testing.T) {
+	testCases := []struct {
+		price    float64
+		side     futures.SideType
+		slippage float64
+		expected string
+	}{
+		{price: 40, side: futures.SideTypeBuy, slippage: 1, expected: "40.4"},
+		{price: 40, side: futures.SideTypeSell, slippage: 1, expected: "39.6"},
+		// 1.23456 * 1.01 = 1.2469056, rounded down to stay within the slippage
+		{price: 1.23456, side: futures.SideTypeBuy, slippage: 1, expected: "1.24"},
+		// 1.23456 * 0.99 = 1.2222144, rounded up
+		{price: 1.23456, side: futures.SideTypeSell, slippage: 1, expected: "1.23"},
+		{price: 40, side: futures.SideTypeBuy, slippage: 0, expected: "40"},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			actual := limitPriceOf(decimal.NewFromFloat(tc.price), tc.side, tc.slippage, decimal.NewFromFloat(0.01))
+			assert.Equal(t, tc.expected, actual.String())
+		})
+	}
+}
+
+func TestParseEntryMode(t *testing.T) {
+	mode, err := ParseEntryMode("limit_ioc")
+	require.NoError(t, err)
+	assert.Equal(t, EntryModeLimitIOC, mode)
+
+	_, err = ParseEntryMode("limit_fok")
+	assert.ErrorIs(t, err, errUnknownEntryMode)
+}
+
+func TestCreateLongPositionWithLimitEntry(t *testing.T) {
+	testCases := []struct {
+		requotes   int
+		limitFills []decimal.Decimal
+		filled     string
+		limitCount int
+	}{
+		{requotes: 0, filled: "2.5", limitCount: 1},
+		{requotes: 2, limitFills: []decimal.Decimal{decimal.NewFromInt(1), decimal.NewFromFloat(0.5)}, filled: "2.5", limitCount: 3},
+		{requotes: 1, limitFills: []decimal.Decimal{decimal.NewFromInt(1), decimal.NewFromFloat(0.5)}, filled: "1.5", limitCount: 2},
+		// nothing filled, no exit order is placed
+		{requotes: 1, limitFills: []decimal.Decimal{decimal.Zero, decimal.Zero}, filled: "", limitCount: 2},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			exchange := newFakeExchange(decimal.NewFromInt(40))
+			exchange.limitFills = tc.limitFills
+
+			m, err := NewBinanceFuturesManager(
+				exchange,
+				zap.NewNop(),
+				WithWillExecuteOrder(true),
+				WithEachTradeAmountInUSD(100),
+				WithEntryMode(EntryModeLimitIOC),
+				WithEntryRequotes(tc.requotes),
+			)
+			require.NoError(t, err)
+			require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+			limitOrders := []*Order{}
+			exitOrders := []*Order{}
+
+			for _, o := range exchange.orders {
+				if o.Type == futures.OrderTypeLimit {
+					limitOrders = append(limitOrders, o)
+				} else {
+					exitOrders = append(exitOrders, o)
+				}
+			}
+
+			require.Len(t, limitOrders, tc.limitCount)
+			assert.Equal(t, "40.4", limitOrders[0].Price.String())
+			assert.Equal(t, "2.5", limitOrders[0].OrigQuantity.String())
+
+			if tc.filled == "" {
+				assert.Empty(t, exitOrders)
+
+				return
+			}
+
+			require.Len(t, exitOrders, 1)
+			assert.Equal(t, futures.OrderTypeTakeProfitMarket, exitOrders[0].Type)
+			assert.Equal(t, "42", exitOrders[0].StopPrice.String())
+
+			filled := decimal.Zero
+			for _, o := range limitOrders {
+				filled = filled.Add(o.ExecutedQuantity)
+			}
+
+			assert.Equal(t, tc.filled, filled.String())
+		})
+	}
+}
+
+func TestLimitEntryRequoteRemainder(t *testing.T) {
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+	exchange.limitFills = []decimal.Decimal{decimal.NewFromInt(1)}
+
+	m, err := NewBinanceFuturesManager(
+		exchange,
+		zap.NewNop(),
+		WithEntryMode(EntryModeLimitIOC),
+		WithEntryRequotes(1),
+	)
+	require.NoError(t, err)
+
+	futuresSymbol, err := m.getSymbol("SOLUSDT")
+	require.NoError(t, err)
+
+	entryOrder, err := m.placeEntryOrder(context.Background(), futuresSymbol, futures.SideTypeBuy, decimal.NewFromInt(40), decimal.NewFromFloat(2.5))
+	require.NoError(t, err)
+	require.NotNil(t, entryOrder)
+	assert.Equal(t, "2.5", entryOrder.ExecutedQuantity.String())
+	assert.Equal(t, "2.5", entryOrder.OrigQuantity.String())
+	assert.Equal(t, "40", entryOrder.AvgPrice.String())
+
+	require.Len(t, exchange.orders, 2)
+	assert.Equal(t, "1.5", exchange.orders[1].OrigQuantity.String(), "only the remainder is requoted")
+}
+
+func TestLimitGTXEntryCancelled(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperInitialBalanceInUSD(1000),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithEntryMode(EntryModeLimitGTX),
+		WithEntryMaxSlippagePercentage(-1),
+		WithEntryRequoteInterval(time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	futuresSymbol, err := m.getSymbol("SOLUSDT")
+	require.NoError(t, err)
+
+	entryOrder, err := m.placeEntryOrder(context.Background(), futuresSymbol, futures.SideTypeBuy, decimal.NewFromInt(40), decimal.NewFromFloat(2.5))
+	require.NoError(t, err)
+	assert.Nil(t, entryOrder)
+
+	o, err := paper.GetOrder(context.Background(), "SOLUSDT", 1)
+	require.NoError(t, err)
+	assert.Equal(t, futures.OrderStatusTypeCanceled, o.Status)
+	assert.Equal(t, "39.6", o.Price.String())
+}
+
+func TestLimitGTXEntryCancelledWithContext(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperInitialBalanceInUSD(1000),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithEntryMode(EntryModeLimitGTX),
+		WithEntryMaxSlippagePercentage(-1),
+		WithEntryRequoteInterval(time.Hour),
+	)
+	require.NoError(t, err)
+
+	futuresSymbol, err := m.getSymbol("SOLUSDT")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	entryOrder, err := m.placeEntryOrder(ctx, futuresSymbol, futures.SideTypeBuy, decimal.NewFromInt(40), decimal.NewFromFloat(2.5))
+	require.NoError(t, err)
+	assert.Nil(t, entryOrder)
+
+	o, err := paper.GetOrder(context.Background(), "SOLUSDT", 1)
+	require.NoError(t, err)
+	assert.Equal(t, futures.OrderStatusTypeCanceled, o.Status)
+}
+
+func TestLimitGTXEntryWithPositiveSlippage(t *testing.T) {
+	_, err := NewBinanceFuturesManager(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithEntryMode(EntryModeLimitGTX),
+		WithEntryMaxSlippagePercentage(0.5),
+	)
+	assert.ErrorIs(t, err, errPostOnlyEntryAboveMarket)
+}
@@ -0,0 +1,64 @@
+package trading
+
+import (
+	"context"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+type Exchange interface {
+	GetSymbolsInfo(ctx context.Context) (map[string]futures.Symbol, error)
+	GetPrice(ctx context.Context, symbol string) (decimal.Decimal, error)
+	ChangeLeverage(ctx context.Context, symbol string, leverage int) error
+	CreateOrder(ctx context.Context, req OrderRequest) (*Order, error)
+	CancelOrder(ctx context.Context, symbol string, orderID int64) error
+	GetOrder(ctx context.Context, symbol string, orderID int64) (*Order, error)
+	ListOpenOrders(ctx context.Context, symbol string) ([]*Order, error)
+	GetPositions(ctx context.Context) ([]*Position, error)
+	// GetAvailableBalance returns the balance of the asset that can be put up as the margin of a new position.
+	GetAvailableBalance(ctx context.Context, asset string) (decimal.Decimal, error)
+	// GetRealizedPnL returns the sum of the profit and loss realized by the positions closed since the time.
+	GetRealizedPnL(ctx context.Context, since time.Time) (decimal.Decimal, error)
+}
+
+type OrderRequest struct {
+	Symbol          string
+	Side            futures.SideType
+	Type            futures.OrderType
+	TimeInForce     futures.TimeInForceType
+	Quantity        decimal.Decimal
+	Price           decimal.Decimal
+	StopPrice       decimal.Decimal
+	ActivationPrice decimal.Decimal
+	CallbackRate    decimal.Decimal
+	ReduceOnly      bool
+	ClosePosition   bool
+}
+
+type Order struct {
+	Symbol           string
+	OrderID          int64
+	Side             futures.SideType
+	Type             futures.OrderType
+	Status           futures.OrderStatusType
+	Price            decimal.Decimal
+	StopPrice        decimal.Decimal
+	OrigQuantity     decimal.Decimal
+	ExecutedQuantity decimal.Decimal
+	AvgPrice         decimal.Decimal
+	ActivationPrice  decimal.Decimal
+	CallbackRate     decimal.Decimal
+	ReduceOnly       bool
+	// only known for the order returned by CreateOrder, binance does not return it for a queried order
+	ClosePosition bool
+}
+
+type Position struct {
+	Symbol           string
+	Amount           decimal.Decimal
+	EntryPrice       decimal.Decimal
+	MarkPrice        decimal.Decimal
+	UnrealizedProfit decimal.Decimal
+}
@@ -0,0 +1,357 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/pkg/journal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// placeExitOrders places the take profit or trailing stop orders and the stop loss order of the position. The
+// stop loss order is attempted even if the others fail, so that the position is never left without it.
+func (m *BinanceFuturesManager) placeExitOrders(ctx context.Context, futuresSymbol futures.Symbol, entryOrder *Order) error {
+	symbol := futuresSymbol.Symbol
+	avgPrice := entryOrder.AvgPrice
+	exitSide, direction := exitSideOf(entryOrder.Side)
+
+	tickSize, err := decimal.NewFromString(futuresSymbol.PriceFilter().TickSize)
+	if err != nil {
+		return fmt.Errorf("convert tick size string to decimal: %w", err)
+	}
+
+	orderIDs, profitErr := m.placeProfitExitOrders(ctx, futuresSymbol, entryOrder, tickSize)
+
+	var stopLossErr error
+
+	if m.futuresOpts.stopLossPriceChangedPercentage > 0 {
+		stopLossPrice := roundToTickSize(priceChangedBy(avgPrice, -direction*m.futuresOpts.stopLossPriceChangedPercentage), tickSize)
+
+		stopLossOrder, err := m.createOrder(ctx, journal.OrderKindExit, OrderRequest{
+			Symbol:        symbol,
+			Side:          exitSide,
+			Type:          futures.OrderTypeStopMarket,
+			TimeInForce:   futures.TimeInForceTypeGTC,
+			ClosePosition: true,
+			StopPrice:     stopLossPrice,
+		})
+		if err != nil {
+			stopLossErr = fmt.Errorf("create stop loss order: %w", err)
+		} else {
+			orderIDs = append(orderIDs, stopLossOrder.OrderID)
+		}
+	}
+
+	if len(orderIDs) > 0 {
+		m.trackExitOrders(symbol, orderIDs)
+	}
+
+	switch {
+	case profitErr != nil && stopLossErr != nil:
+		return fmt.Errorf("%s, %w", profitErr.Error(), stopLossErr)
+	case profitErr != nil:
+		return profitErr
+	default:
+		return stopLossErr
+	}
+}
+
+// placeProfitExitOrders places the orders taking the profit by the exit mode, the ids of the ones placed are
+// returned even on failure.
+func (m *BinanceFuturesManager) placeProfitExitOrders(
+	ctx context.Context,
+	futuresSymbol futures.Symbol,
+	entryOrder *Order,
+	tickSize decimal.Decimal,
+) ([]int64, error) {
+	symbol := futuresSymbol.Symbol
+	avgPrice := entryOrder.AvgPrice
+	exitSide, direction := exitSideOf(entryOrder.Side)
+
+	switch m.futuresOpts.exitMode {
+	case ExitModeTrailingStop:
+		req := OrderRequest{
+			Symbol:       symbol,
+			Side:         exitSide,
+			Type:         futures.OrderTypeTrailingStopMarket,
+			TimeInForce:  futures.TimeInForceTypeGTC,
+			Quantity:     entryOrder.ExecutedQuantity,
+			CallbackRate: decimal.NewFromFloat(m.futuresOpts.trailingStopCallbackRate),
+			ReduceOnly:   true,
+		}
+		if m.futuresOpts.trailingStopActivationPercentage > 0 {
+			req.ActivationPrice = roundToTickSize(priceChangedBy(avgPrice, direction*m.futuresOpts.trailingStopActivationPercentage), tickSize)
+		}
+
+		trailingStopOrder, err := m.createOrder(ctx, journal.OrderKindExit, req)
+		if err != nil {
+			return nil, fmt.Errorf("create trailing stop order: %w", err)
+		}
+
+		return []int64{trailingStopOrder.OrderID}, nil
+	case ExitModeTakeProfit:
+		if len(m.futuresOpts.takeProfitLadder) > 0 {
+			return m.placeTakeProfitLadder(ctx, futuresSymbol, entryOrder, tickSize)
+		}
+
+		takeProfitPrice := roundToTickSize(priceChangedBy(avgPrice, direction*m.futuresOpts.takeProfitPriceChangedPercentage), tickSize)
+
+		takeProfitOrder, err := m.createOrder(ctx, journal.OrderKindExit, OrderRequest{
+			Symbol:        symbol,
+			Side:          exitSide,
+			Type:          futures.OrderTypeTakeProfitMarket,
+			TimeInForce:   futures.TimeInForceTypeGTC,
+			ClosePosition: true,
+			StopPrice:     takeProfitPrice,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create take profit order: %w", err)
+		}
+
+		return []int64{takeProfitOrder.OrderID}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownExitMode, m.futuresOpts.exitMode)
+	}
+}
+
+func (m *BinanceFuturesManager) placeTakeProfitLadder(
+	ctx context.Context,
+	futuresSymbol futures.Symbol,
+	entryOrder *Order,
+	tickSize decimal.Decimal,
+) ([]int64, error) {
+	levels := m.futuresOpts.takeProfitLadder
+	quantities := splitLadderQuantities(entryOrder.ExecutedQuantity, levels, int32(futuresSymbol.QuantityPrecision))
+	orderIDs := []int64{}
+	exitSide, direction := exitSideOf(entryOrder.Side)
+
+	for i, level := range levels {
+		if quantities[i].IsZero() {
+			continue
+		}
+
+		takeProfitPrice := roundToTickSize(priceChangedBy(entryOrder.AvgPrice, direction*level.PriceChangedPercentage), tickSize)
+
+		o, err := m.createOrder(ctx, journal.OrderKindExit, OrderRequest{
+			Symbol:      futuresSymbol.Symbol,
+			Side:        exitSide,
+			Type:        futures.OrderTypeTakeProfitMarket,
+			TimeInForce: futures.TimeInForceTypeGTC,
+			Quantity:    quantities[i],
+			StopPrice:   takeProfitPrice,
+			ReduceOnly:  true,
+		})
+		if err != nil {
+			return orderIDs, fmt.Errorf("create take profit ladder order at %v%%: %w", level.PriceChangedPercentage, err)
+		}
+
+		orderIDs = append(orderIDs, o.OrderID)
+	}
+
+	return orderIDs, nil
+}
+
+// splitLadderQuantities rounds each level's share down to the quantity precision. Whatever is left,
+// including the rounding dust and any share not covered by the levels, is added to the last level.
+func splitLadderQuantities(qty decimal.Decimal, levels []TakeProfitLevel, precision int32) []decimal.Decimal {
+	res := make([]decimal.Decimal, len(levels))
+	remaining := qty
+
+	for i, level := range levels[:len(levels)-1] {
+		res[i] = qty.Mul(decimal.NewFromFloat(level.QuantityPercentage)).
+			Div(decimal.NewFromInt(100)). // nolint: gomnd
+			Truncate(precision)
+		remaining = remaining.Sub(res[i])
+	}
+
+	res[len(levels)-1] = remaining
+
+	return res
+}
+
+// CheckExitOrders cancels the remaining exit orders of a position once the position has been closed, either by
+// an exit order or manually. A symbol failing to be checked is logged and checked again the next time. Only the
+// orders checked are untracked, the exit orders may be replaced in the meantime by adding to the position.
+func (m *BinanceFuturesManager) CheckExitOrders() error {
+	ctx := context.Background()
+
+	var positions map[string]decimal.Decimal
+
+	for symbol, orderIDs := range m.getExitOrders() {
+		openOrderIDs, err := m.getOpenExitOrderIDs(ctx, symbol, orderIDs)
+		if err != nil {
+			m.logger.Error("Fail to check exit orders", zap.String("symbol", symbol), zap.Error(err))
+
+			continue
+		}
+
+		if len(openOrderIDs) > 0 {
+			if positions == nil {
+				if positions, err = m.getPositionAmounts(ctx); err != nil {
+					return err
+				}
+			}
+
+			// the position is still open, e.g. only a take profit ladder level has been filled
+			if !positions[symbol].IsZero() {
+				continue
+			}
+		}
+
+		if err := m.cancelExitOrders(ctx, symbol, openOrderIDs); err != nil {
+			m.logger.Error("Fail to cancel remaining exit orders", zap.String("symbol", symbol), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// getOpenExitOrderIDs returns the exit orders still in the order book. The ones that are done are journaled and
+// untracked, they would otherwise be fetched on every check.
+func (m *BinanceFuturesManager) getOpenExitOrderIDs(ctx context.Context, symbol string, orderIDs []int64) ([]int64, error) {
+	openOrderIDs := []int64{}
+	doneOrderIDs := []int64{}
+
+	defer func() {
+		m.untrackExitOrderIDs(symbol, doneOrderIDs)
+	}()
+
+	for _, orderID := range orderIDs {
+		o, err := m.exchange.GetOrder(ctx, symbol, orderID)
+		if err != nil {
+			return nil, fmt.Errorf("get exit order: %w", err)
+		}
+
+		if isOrderOpen(o) {
+			openOrderIDs = append(openOrderIDs, orderID)
+
+			continue
+		}
+
+		m.recordOrder(journal.OrderKindExit, o)
+
+		doneOrderIDs = append(doneOrderIDs, orderID)
+	}
+
+	return openOrderIDs, nil
+}
+
+// cancelExitOrders cancels the exit orders of a closed position, the ones cancelled are untracked even if
+// another one fails.
+func (m *BinanceFuturesManager) cancelExitOrders(ctx context.Context, symbol string, orderIDs []int64) error {
+	for i, orderID := range orderIDs {
+		if err := m.cancelOrder(ctx, journal.OrderKindExit, symbol, orderID); err != nil {
+			m.untrackExitOrderIDs(symbol, orderIDs[:i])
+
+			return fmt.Errorf("cancel exit order: %w", err)
+		}
+
+		m.logger.Info("Cancelled remaining exit order", zap.String("symbol", symbol), zap.Int64("orderID", orderID))
+	}
+
+	m.untrackExitOrderIDs(symbol, orderIDs)
+
+	return nil
+}
+
+func (m *BinanceFuturesManager) getPositionAmounts(ctx context.Context) (map[string]decimal.Decimal, error) {
+	positions, err := m.exchange.GetPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get positions: %w", err)
+	}
+
+	res := make(map[string]decimal.Decimal, len(positions))
+	for _, p := range positions {
+		res[p.Symbol] = p.Amount
+	}
+
+	return res, nil
+}
+
+func (m *BinanceFuturesManager) trackExitOrders(symbol string, orderIDs []int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.exitOrders[symbol] = orderIDs
+}
+
+func (m *BinanceFuturesManager) untrackExitOrders(symbol string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.exitOrders, symbol)
+}
+
+// untrackExitOrderIDs untracks the orders of the symbol, the others tracked are kept.
+func (m *BinanceFuturesManager) untrackExitOrderIDs(symbol string, orderIDs []int64) {
+	if len(orderIDs) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	untracked := make(map[int64]struct{}, len(orderIDs))
+	for _, orderID := range orderIDs {
+		untracked[orderID] = struct{}{}
+	}
+
+	res := []int64{}
+
+	for _, orderID := range m.exitOrders[symbol] {
+		if _, ok := untracked[orderID]; !ok {
+			res = append(res, orderID)
+		}
+	}
+
+	if len(res) == 0 {
+		delete(m.exitOrders, symbol)
+
+		return
+	}
+
+	m.exitOrders[symbol] = res
+}
+
+func (m *BinanceFuturesManager) getExitOrders() map[string][]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[string][]int64, len(m.exitOrders))
+	for symbol, orderIDs := range m.exitOrders {
+		res[symbol] = append([]int64{}, orderIDs...)
+	}
+
+	return res
+}
+
+// isExitOrder returns whether the order closes a position. The conditional orders are only placed as exits,
+// and tell a close position one apart as its flag is not returned for a queried order.
+func isExitOrder(o *Order) bool {
+	switch o.Type {
+	case futures.OrderTypeTakeProfitMarket, futures.OrderTypeStopMarket, futures.OrderTypeTrailingStopMarket:
+		return true
+	default:
+		return o.ReduceOnly || o.ClosePosition
+	}
+}
+
+// exitSideOf returns the side of the orders that close a position opened by the entry side,
+// and 1 or -1 as the direction a price has to move for the position to profit.
+func exitSideOf(entrySide futures.SideType) (futures.SideType, float64) {
+	if entrySide == futures.SideTypeSell {
+		return futures.SideTypeBuy, -1
+	}
+
+	return futures.SideTypeSell, 1
+}
+
+func priceChangedBy(price decimal.Decimal, percentage float64) decimal.Decimal {
+	multiplier := decimal.NewFromFloat(percentage).
+		Div(decimal.NewFromInt(100)). // nolint: gomnd
+		Add(decimal.NewFromInt(1))
+
+	return price.Mul(multiplier)
+}
@@ -0,0 +1,273 @@
+package trading
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/api"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseTakeProfitLadder(t *testing.T) {
+	levels, err := ParseTakeProfitLadder("5:40, 10:30,20:30")
+	require.NoError(t, err)
+	assert.Equal(t, []TakeProfitLevel{
+		{PriceChangedPercentage: 5, QuantityPercentage: 40},
+		{PriceChangedPercentage: 10, QuantityPercentage: 30},
+		{PriceChangedPercentage: 20, QuantityPercentage: 30},
+	}, levels)
+
+	for _, in := range []string{"5", "5:40:1", "x:40", "5:-1", "5:60,10:50"} {
+		_, err := ParseTakeProfitLadder(in)
+		assert.ErrorIs(t, err, errInvalidTakeProfitLadder, in)
+	}
+}
+
+func TestSplitLadderQuantities(t *testing.T) {
+	testCases := []struct {
+		qty       string
+		levels    []TakeProfitLevel
+		precision int32
+
+		out []string
+	}{
+		{
+			qty: "2.5",
+			levels: []TakeProfitLevel{
+				{PriceChangedPercentage: 5, QuantityPercentage: 40},
+				{PriceChangedPercentage: 10, QuantityPercentage: 30},
+				{PriceChangedPercentage: 20, QuantityPercentage: 30},
+			},
+			precision: 1,
+			out:       []string{"1", "0.7", "0.8"},
+		},
+		{
+			qty: "3",
+			levels: []TakeProfitLevel{
+				{PriceChangedPercentage: 5, QuantityPercentage: 50},
+				{PriceChangedPercentage: 10, QuantityPercentage: 20},
+			},
+			precision: 0,
+			out:       []string{"1", "2"},
+		},
+		{
+			qty: "1",
+			levels: []TakeProfitLevel{
+				{PriceChangedPercentage: 5, QuantityPercentage: 10},
+				{PriceChangedPercentage: 10, QuantityPercentage: 90},
+			},
+			precision: 0,
+			out:       []string{"0", "1"},
+		},
+	}
+	for i, tt := range testCases {
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			qty, err := decimal.NewFromString(tt.qty)
+			require.NoError(t, err)
+
+			res := []string{}
+			for _, q := range splitLadderQuantities(qty, tt.levels, tt.precision) {
+				res = append(res, q.String())
+			}
+
+			assert.Equal(t, tt.out, res)
+		})
+	}
+}
+
+func TestTakeProfitLadderWithStopLoss(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithStopLossPriceChangedPercentage(2.5),
+		WithTakeProfitLadder(
+			TakeProfitLevel{PriceChangedPercentage: 5, QuantityPercentage: 40},
+			TakeProfitLevel{PriceChangedPercentage: 10, QuantityPercentage: 30},
+			TakeProfitLevel{PriceChangedPercentage: 20, QuantityPercentage: 30},
+		),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	openOrders, err := paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 4)
+
+	ladder := map[string]string{}
+	for _, o := range openOrders {
+		if o.Type == futures.OrderTypeTakeProfitMarket {
+			assert.True(t, o.ReduceOnly)
+			ladder[o.StopPrice.String()] = o.OrigQuantity.String()
+		}
+	}
+
+	assert.Equal(t, map[string]string{"42": "1", "44": "0.7", "48": "0.8"}, ladder)
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(42))
+	require.NoError(t, m.CheckExitOrders())
+
+	openOrders, err = paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	assert.Len(t, openOrders, 3)
+	assert.Len(t, m.getExitOrders()["SOLUSDT"], 3, "the filled level is no longer tracked")
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(39))
+	require.NoError(t, m.CheckExitOrders())
+
+	openOrders, err = paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders)
+	assert.Empty(t, m.getExitOrders())
+	assert.Equal(t, "0.5", paper.PnL().RealizedPnL.String())
+}
+
+func TestCheckExitOrdersOfManuallyClosedPosition(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+		WithStopLossPriceChangedPercentage(2.5),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	// an exit order of a symbol that cannot be checked does not hold up the others
+	m.trackExitOrders("BTCUSDT", []int64{100})
+
+	_, err = paper.CreateOrder(context.Background(), OrderRequest{
+		Symbol:     "SOLUSDT",
+		Side:       futures.SideTypeSell,
+		Type:       futures.OrderTypeMarket,
+		Quantity:   decimal.NewFromFloat(2.5),
+		ReduceOnly: true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, m.CheckExitOrders())
+
+	openOrders, err := paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders)
+	assert.Equal(t, map[string][]int64{"BTCUSDT": {100}}, m.getExitOrders())
+}
+
+// hookExchange calls the hook once before getting an order.
+type hookExchange struct {
+	Exchange
+	beforeGetOrder func()
+}
+
+func (e *hookExchange) GetOrder(ctx context.Context, symbol string, orderID int64) (*Order, error) {
+	if hook := e.beforeGetOrder; hook != nil {
+		e.beforeGetOrder = nil
+		hook()
+	}
+
+	return e.Exchange.GetOrder(ctx, symbol, orderID)
+}
+
+func TestCheckExitOrdersKeepsReplacedExitOrders(t *testing.T) {
+	exchange := &hookExchange{
+		Exchange: NewPaperExchange(
+			newFakeExchange(decimal.NewFromInt(40)),
+			zap.NewNop(),
+			WithPaperSlippagePercentage(0),
+		),
+	}
+
+	m, err := NewBinanceFuturesManager(
+		exchange,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+		WithAddOnStages(api.SignalStageTradingLive),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	checked := m.getExitOrders()["SOLUSDT"]
+
+	// the position is added to while its exit orders are being checked
+	exchange.beforeGetOrder = func() {
+		require.NoError(t, m.ConsumeBuySignal(api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTradingLive}))
+	}
+
+	require.NoError(t, m.CheckExitOrders())
+
+	replaced := m.getExitOrders()["SOLUSDT"]
+	require.Len(t, replaced, 1, "only the exit orders checked are untracked")
+	assert.NotEqual(t, checked, replaced)
+}
+
+func TestTrailingStopCallbackRateOutOfRange(t *testing.T) {
+	testCases := []struct {
+		rate float64
+		err  error
+	}{
+		{rate: 0.1, err: nil},
+		{rate: 5, err: nil},
+		{rate: 0.05, err: errInvalidTrailingStopCallbackRate},
+		{rate: 10, err: errInvalidTrailingStopCallbackRate},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			_, err := NewBinanceFuturesManager(
+				newFakeExchange(decimal.NewFromInt(40)),
+				zap.NewNop(),
+				WithExitMode(ExitModeTrailingStop),
+				WithTrailingStopCallbackRate(tc.rate),
+			)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestStopLossPlacedWhenTakeProfitFails(t *testing.T) {
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+	exchange.rejectedOrderType = futures.OrderTypeTakeProfitMarket
+
+	m, err := NewBinanceFuturesManager(
+		exchange,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+		WithStopLossPriceChangedPercentage(2.5),
+	)
+	require.NoError(t, err)
+
+	err = m.createLongPosition("SOLUSDT")
+	assert.ErrorIs(t, err, errFakeOrderRejected)
+
+	openOrders, err := exchange.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 1)
+	assert.Equal(t, futures.OrderTypeStopMarket, openOrders[0].Type)
+	assert.Equal(t, "39", openOrders[0].StopPrice.String())
+	assert.Equal(t, []int64{openOrders[0].OrderID}, m.getExitOrders()["SOLUSDT"])
+}
@@ -1,11 +1,94 @@
 package trading
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/journal"
+)
+
 const (
 	defaultTakeProfitPriceChangedPercentage = 5.0
 	defaultEachTradeAmountInUSD             = 500.0
 	defaultLeverage                         = 5
+	defaultTrailingStopCallbackRate         = 1.0
+	minTrailingStopCallbackRate             = 0.1
+	maxTrailingStopCallbackRate             = 5.0
+	defaultEntryMaxSlippagePercentage       = 1.0
+	defaultEntryRequoteInterval             = 5 * time.Second
+	defaultPaperInitialBalanceInUSD         = 10000.0
+	defaultPaperSlippagePercentage          = 0.1
 )
 
+var (
+	errUnknownExitMode                 = errors.New("unknown exit mode")
+	errInvalidTakeProfitLadder         = errors.New("invalid take profit ladder")
+	errInvalidTrailingStopCallbackRate = errors.New("trailing stop callback rate out of [0.1, 5]")
+)
+
+type ExitMode string
+
+const (
+	ExitModeTakeProfit   ExitMode = "take_profit"
+	ExitModeTrailingStop ExitMode = "trailing_stop"
+)
+
+func ParseExitMode(s string) (ExitMode, error) {
+	switch mode := ExitMode(s); mode {
+	case ExitModeTakeProfit, ExitModeTrailingStop:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %s", errUnknownExitMode, s)
+	}
+}
+
+// TakeProfitLevel closes QuantityPercentage of the filled quantity once the price rises by PriceChangedPercentage.
+type TakeProfitLevel struct {
+	PriceChangedPercentage float64
+	QuantityPercentage     float64
+}
+
+// ParseTakeProfitLadder parses levels in the form of "5:40,10:30,20:30",
+// i.e. comma separated pairs of price changed percentage and quantity percentage.
+func ParseTakeProfitLadder(s string) ([]TakeProfitLevel, error) {
+	res := []TakeProfitLevel{}
+	totalQuantityPercentage := 0.0
+
+	for _, level := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(level), ":")
+		if len(parts) != 2 { // nolint: gomnd
+			return nil, fmt.Errorf("%w: %q", errInvalidTakeProfitLadder, level)
+		}
+
+		priceChangedPercentage, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil || priceChangedPercentage <= 0 {
+			return nil, fmt.Errorf("%w: price changed percentage %q", errInvalidTakeProfitLadder, parts[0])
+		}
+
+		quantityPercentage, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || quantityPercentage <= 0 {
+			return nil, fmt.Errorf("%w: quantity percentage %q", errInvalidTakeProfitLadder, parts[1])
+		}
+
+		totalQuantityPercentage += quantityPercentage
+
+		res = append(res, TakeProfitLevel{
+			PriceChangedPercentage: priceChangedPercentage,
+			QuantityPercentage:     quantityPercentage,
+		})
+	}
+
+	if totalQuantityPercentage > 100 { // nolint: gomnd
+		return nil, fmt.Errorf("%w: quantity percentages sum to %v", errInvalidTakeProfitLadder, totalQuantityPercentage)
+	}
+
+	return res, nil
+}
+
 type FuturesOption interface {
 	apply(*futuresOptions)
 }
@@ -13,16 +96,44 @@ type FuturesOption interface {
 type futuresOptions struct {
 	takeProfitPriceChangedPercentage float64
 	eachTradeAmountInUSD             float64
+	stopLossPriceChangedPercentage   float64
+	takeProfitLadder                 []TakeProfitLevel
+	exitMode                         ExitMode
+	trailingStopCallbackRate         float64
+	trailingStopActivationPercentage float64
+	maxHoldingDuration               time.Duration
+	deadlineStore                    DeadlineStore
+	journal                          *journal.Journal
 	leverage                         int
 	willExecuteOrder                 bool
+	entryStages                      []api.SignalStage
+	addOnStages                      []api.SignalStage
+	shortSelling                     bool
+	quoteAssets                      []string
+	sizingMode                       SizingMode
+	balancePercentage                float64
+	riskPerTradeInUSD                float64
+	maxNotionalInUSD                 float64
+	entryMode                        EntryMode
+	entryMaxSlippagePercentage       float64
+	entryRequotes                    int
+	entryRequoteInterval             time.Duration
 }
 
 func newDefaultFuturesOptions() futuresOptions {
 	return futuresOptions{
 		takeProfitPriceChangedPercentage: defaultTakeProfitPriceChangedPercentage,
 		eachTradeAmountInUSD:             defaultEachTradeAmountInUSD,
+		exitMode:                         ExitModeTakeProfit,
+		trailingStopCallbackRate:         defaultTrailingStopCallbackRate,
 		leverage:                         defaultLeverage,
 		willExecuteOrder:                 false,
+		entryStages:                      []api.SignalStage{api.SignalStageTransfers},
+		quoteAssets:                      []string{"USDT", "BUSD", "USDC"},
+		sizingMode:                       SizingModeFixed,
+		entryMode:                        EntryModeMarket,
+		entryMaxSlippagePercentage:       defaultEntryMaxSlippagePercentage,
+		entryRequoteInterval:             defaultEntryRequoteInterval,
 	}
 }
 
@@ -36,6 +147,63 @@ func WithTakeProfitPriceChangedPercentage(f float64) FuturesOption {
 	return takeProfitPriceChangedPercentageOption(f)
 }
 
+type takeProfitLadderOption []TakeProfitLevel
+
+func (c takeProfitLadderOption) apply(opts *futuresOptions) {
+	opts.takeProfitLadder = []TakeProfitLevel(c)
+}
+
+// WithTakeProfitLadder replaces the single take profit order with reduce only orders at each level,
+// the quantity not covered by the levels is closed at the last level.
+func WithTakeProfitLadder(levels ...TakeProfitLevel) FuturesOption {
+	return takeProfitLadderOption(levels)
+}
+
+type stopLossPriceChangedPercentageOption float64
+
+func (c stopLossPriceChangedPercentageOption) apply(opts *futuresOptions) {
+	opts.stopLossPriceChangedPercentage = float64(c)
+}
+
+// WithStopLossPriceChangedPercentage places a stop market order below the entry price, zero disables it.
+func WithStopLossPriceChangedPercentage(f float64) FuturesOption {
+	return stopLossPriceChangedPercentageOption(f)
+}
+
+type exitModeOption ExitMode
+
+func (c exitModeOption) apply(opts *futuresOptions) {
+	opts.exitMode = ExitMode(c)
+}
+
+// WithExitMode selects between a fixed take profit order and a trailing stop order.
+func WithExitMode(mode ExitMode) FuturesOption {
+	return exitModeOption(mode)
+}
+
+type trailingStopCallbackRateOption float64
+
+func (c trailingStopCallbackRateOption) apply(opts *futuresOptions) {
+	opts.trailingStopCallbackRate = float64(c)
+}
+
+// WithTrailingStopCallbackRate sets the callback rate in percent, binance accepts 0.1 to 5.
+func WithTrailingStopCallbackRate(f float64) FuturesOption {
+	return trailingStopCallbackRateOption(f)
+}
+
+type trailingStopActivationPercentageOption float64
+
+func (c trailingStopActivationPercentageOption) apply(opts *futuresOptions) {
+	opts.trailingStopActivationPercentage = float64(c)
+}
+
+// WithTrailingStopActivationPriceChangedPercentage activates the trailing stop once the price rises by the
+// percentage above the entry price, zero activates it immediately.
+func WithTrailingStopActivationPriceChangedPercentage(f float64) FuturesOption {
+	return trailingStopActivationPercentageOption(f)
+}
+
 type eachTradeAmountInUSDOption float64
 
 func (c eachTradeAmountInUSDOption) apply(opts *futuresOptions) {
@@ -56,6 +224,43 @@ func WithLeverage(l int) FuturesOption {
 	return leverageOption(l)
 }
 
+type maxHoldingDurationOption time.Duration
+
+func (c maxHoldingDurationOption) apply(opts *futuresOptions) {
+	opts.maxHoldingDuration = time.Duration(c)
+}
+
+// WithMaxHoldingDuration closes the position with a market order once it has been held for d, zero disables it.
+func WithMaxHoldingDuration(d time.Duration) FuturesOption {
+	return maxHoldingDurationOption(d)
+}
+
+type deadlineStoreOption struct {
+	store DeadlineStore
+}
+
+func (c deadlineStoreOption) apply(opts *futuresOptions) {
+	opts.deadlineStore = c.store
+}
+
+// WithDeadlineStore persists the max holding duration deadlines so that they survive restarts.
+func WithDeadlineStore(store DeadlineStore) FuturesOption {
+	return deadlineStoreOption{store: store}
+}
+
+type journalOption struct {
+	journal *journal.Journal
+}
+
+func (c journalOption) apply(opts *futuresOptions) {
+	opts.journal = c.journal
+}
+
+// WithJournal records every order placed by the manager and its fills.
+func WithJournal(j *journal.Journal) FuturesOption {
+	return journalOption{journal: j}
+}
+
 type willExecuteOrderOption bool
 
 func (c willExecuteOrderOption) apply(opts *futuresOptions) {
@@ -65,3 +270,243 @@ func (c willExecuteOrderOption) apply(opts *futuresOptions) {
 func WithWillExecuteOrder(f bool) FuturesOption {
 	return willExecuteOrderOption(f)
 }
+
+type entryStagesOption []api.SignalStage
+
+func (c entryStagesOption) apply(opts *futuresOptions) {
+	opts.entryStages = c
+}
+
+// WithEntryStages sets the signal stages that open a position, signals without a stage always do.
+func WithEntryStages(stages ...api.SignalStage) FuturesOption {
+	return entryStagesOption(stages)
+}
+
+type addOnStagesOption []api.SignalStage
+
+func (c addOnStagesOption) apply(opts *futuresOptions) {
+	opts.addOnStages = c
+}
+
+// WithAddOnStages sets the signal stages that add to a position opened by an earlier stage.
+func WithAddOnStages(stages ...api.SignalStage) FuturesOption {
+	return addOnStagesOption(stages)
+}
+
+type shortSellingOption bool
+
+func (c shortSellingOption) apply(opts *futuresOptions) {
+	opts.shortSelling = bool(c)
+}
+
+// WithShortSelling opens short positions on sell signals of symbols without a position.
+func WithShortSelling(f bool) FuturesOption {
+	return shortSellingOption(f)
+}
+
+type quoteAssetsOption []string
+
+func (c quoteAssetsOption) apply(opts *futuresOptions) {
+	opts.quoteAssets = c
+}
+
+// WithQuoteAssets sets the quote assets of the contracts a coin is traded with, in the order of preference.
+func WithQuoteAssets(assets ...string) FuturesOption {
+	return quoteAssetsOption(assets)
+}
+
+type sizingModeOption SizingMode
+
+func (c sizingModeOption) apply(opts *futuresOptions) {
+	opts.sizingMode = SizingMode(c)
+}
+
+// WithSizingMode selects how the notional amount of a new position is decided, the fixed each trade amount by default.
+func WithSizingMode(mode SizingMode) FuturesOption {
+	return sizingModeOption(mode)
+}
+
+type balancePercentageOption float64
+
+func (c balancePercentageOption) apply(opts *futuresOptions) {
+	opts.balancePercentage = float64(c)
+}
+
+// WithBalancePercentage sets the percentage of the available balance put up as the margin of the balance_percentage sizing.
+func WithBalancePercentage(f float64) FuturesOption {
+	return balancePercentageOption(f)
+}
+
+type riskPerTradeInUSDOption float64
+
+func (c riskPerTradeInUSDOption) apply(opts *futuresOptions) {
+	opts.riskPerTradeInUSD = float64(c)
+}
+
+// WithRiskPerTradeInUSD sets the loss at the stop loss of the fixed_risk sizing.
+func WithRiskPerTradeInUSD(f float64) FuturesOption {
+	return riskPerTradeInUSDOption(f)
+}
+
+type maxNotionalInUSDOption float64
+
+func (c maxNotionalInUSDOption) apply(opts *futuresOptions) {
+	opts.maxNotionalInUSD = float64(c)
+}
+
+// WithMaxNotionalInUSD caps the notional amount of a new position whatever the sizing mode, zero disables it.
+func WithMaxNotionalInUSD(f float64) FuturesOption {
+	return maxNotionalInUSDOption(f)
+}
+
+type entryModeOption EntryMode
+
+func (c entryModeOption) apply(opts *futuresOptions) {
+	opts.entryMode = EntryMode(c)
+}
+
+// WithEntryMode selects the type of the order opening a position, a market order by default.
+func WithEntryMode(mode EntryMode) FuturesOption {
+	return entryModeOption(mode)
+}
+
+type entryMaxSlippagePercentageOption float64
+
+func (c entryMaxSlippagePercentageOption) apply(opts *futuresOptions) {
+	opts.entryMaxSlippagePercentage = float64(c)
+}
+
+// WithEntryMaxSlippagePercentage sets how far from the price the limit entry order is placed.
+func WithEntryMaxSlippagePercentage(f float64) FuturesOption {
+	return entryMaxSlippagePercentageOption(f)
+}
+
+type entryRequotesOption int
+
+func (c entryRequotesOption) apply(opts *futuresOptions) {
+	opts.entryRequotes = int(c)
+}
+
+// WithEntryRequotes sets how many times the unfilled remainder of a limit entry order is sent again at the latest price.
+func WithEntryRequotes(n int) FuturesOption {
+	return entryRequotesOption(n)
+}
+
+type entryRequoteIntervalOption time.Duration
+
+func (c entryRequoteIntervalOption) apply(opts *futuresOptions) {
+	opts.entryRequoteInterval = time.Duration(c)
+}
+
+// WithEntryRequoteInterval sets how long a post only entry order waits in the order book before it is cancelled.
+func WithEntryRequoteInterval(d time.Duration) FuturesOption {
+	return entryRequoteIntervalOption(d)
+}
+
+type PaperOption interface {
+	apply(*paperOptions)
+}
+
+type paperOptions struct {
+	initialBalanceInUSD float64
+	slippagePercentage  float64
+}
+
+func newDefaultPaperOptions() paperOptions {
+	return paperOptions{
+		initialBalanceInUSD: defaultPaperInitialBalanceInUSD,
+		slippagePercentage:  defaultPaperSlippagePercentage,
+	}
+}
+
+type paperInitialBalanceInUSDOption float64
+
+func (c paperInitialBalanceInUSDOption) apply(opts *paperOptions) {
+	opts.initialBalanceInUSD = float64(c)
+}
+
+func WithPaperInitialBalanceInUSD(f float64) PaperOption {
+	return paperInitialBalanceInUSDOption(f)
+}
+
+type paperSlippagePercentageOption float64
+
+func (c paperSlippagePercentageOption) apply(opts *paperOptions) {
+	opts.slippagePercentage = float64(c)
+}
+
+func WithPaperSlippagePercentage(f float64) PaperOption {
+	return paperSlippagePercentageOption(f)
+}
+
+type RiskOption interface {
+	apply(*riskOptions)
+}
+
+type riskOptions struct {
+	maxConcurrentPositions int
+	maxTotalNotionalInUSD  float64
+	dailyLossLimitInUSD    float64
+	entryCooldown          time.Duration
+	killSwitchFile         string
+}
+
+// newDefaultRiskOptions disables every limit.
+func newDefaultRiskOptions() riskOptions {
+	return riskOptions{}
+}
+
+type maxConcurrentPositionsOption int
+
+func (c maxConcurrentPositionsOption) apply(opts *riskOptions) {
+	opts.maxConcurrentPositions = int(c)
+}
+
+// WithMaxConcurrentPositions blocks the entries of new symbols once n positions are open.
+func WithMaxConcurrentPositions(n int) RiskOption {
+	return maxConcurrentPositionsOption(n)
+}
+
+type maxTotalNotionalInUSDOption float64
+
+func (c maxTotalNotionalInUSDOption) apply(opts *riskOptions) {
+	opts.maxTotalNotionalInUSD = float64(c)
+}
+
+// WithMaxTotalNotionalInUSD blocks the entries that would take the notional of the open positions above f.
+func WithMaxTotalNotionalInUSD(f float64) RiskOption {
+	return maxTotalNotionalInUSDOption(f)
+}
+
+type dailyLossLimitInUSDOption float64
+
+func (c dailyLossLimitInUSDOption) apply(opts *riskOptions) {
+	opts.dailyLossLimitInUSD = float64(c)
+}
+
+// WithDailyLossLimitInUSD engages the kill switch once the loss realized since the start of the UTC day reaches f.
+func WithDailyLossLimitInUSD(f float64) RiskOption {
+	return dailyLossLimitInUSDOption(f)
+}
+
+type entryCooldownOption time.Duration
+
+func (c entryCooldownOption) apply(opts *riskOptions) {
+	opts.entryCooldown = time.Duration(c)
+}
+
+// WithEntryCooldown blocks the buy signals of a symbol for d after one has been consumed.
+func WithEntryCooldown(d time.Duration) RiskOption {
+	return entryCooldownOption(d)
+}
+
+type killSwitchFileOption string
+
+func (c killSwitchFileOption) apply(opts *riskOptions) {
+	opts.killSwitchFile = string(c)
+}
+
+// WithKillSwitchFile engages the kill switch while the file exists, its content is logged as the reason.
+func WithKillSwitchFile(path string) RiskOption {
+	return killSwitchFileOption(path)
+}
@@ -0,0 +1,561 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	errInsufficientBalance = errors.New("insufficient paper balance")
+	errUnsupportedOrder    = errors.New("unsupported paper order type")
+	errOrderNotOpen        = errors.New("order is not open")
+)
+
+// PaperExchange simulates order execution on top of another exchange's market data.
+// Market orders are filled at the fetched price plus slippage, conditional orders are held
+// until the price feed crosses their stop price. Limit orders are filled at once if the price plus
+// slippage is within their limit, otherwise IOC ones expire and the others are held until the price
+// feed reaches their limit.
+type PaperExchange struct {
+	marketData Exchange
+	paperOpts  paperOptions
+	logger     *zap.Logger
+
+	mu          sync.Mutex
+	balance     decimal.Decimal
+	realizedPnL decimal.Decimal
+	realized    []paperRealizedPnL
+	nextOrderID int64
+	leverage    map[string]int
+	lastPrices  map[string]decimal.Decimal
+	positions   map[string]*Position
+	orders      map[int64]*Order
+	// best price seen by each activated trailing stop order
+	trailingExtremes map[int64]decimal.Decimal
+}
+
+type paperRealizedPnL struct {
+	at  time.Time
+	pnl decimal.Decimal
+}
+
+type PaperPnL struct {
+	Balance       decimal.Decimal
+	RealizedPnL   decimal.Decimal
+	UnrealizedPnL decimal.Decimal
+}
+
+func NewPaperExchange(marketData Exchange, logger *zap.Logger, opts ...PaperOption) *PaperExchange {
+	options := newDefaultPaperOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &PaperExchange{
+		marketData:       marketData,
+		paperOpts:        options,
+		logger:           logger,
+		balance:          decimal.NewFromFloat(options.initialBalanceInUSD),
+		leverage:         make(map[string]int),
+		lastPrices:       make(map[string]decimal.Decimal),
+		positions:        make(map[string]*Position),
+		orders:           make(map[int64]*Order),
+		trailingExtremes: make(map[int64]decimal.Decimal),
+	}
+}
+
+func (e *PaperExchange) GetSymbolsInfo(ctx context.Context) (map[string]futures.Symbol, error) {
+	return e.marketData.GetSymbolsInfo(ctx)
+}
+
+func (e *PaperExchange) GetPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	price, err := e.marketData.GetPrice(ctx, symbol)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastPrices[symbol] = price
+
+	return price, nil
+}
+
+func (e *PaperExchange) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.leverage[symbol] = leverage
+
+	return nil
+}
+
+func (e *PaperExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	switch req.Type {
+	case futures.OrderTypeMarket:
+		price, err := e.GetPrice(ctx, req.Symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		o := e.newOrder(req)
+		if err := e.fillOrder(o, e.applySlippage(price, o.Side)); err != nil {
+			return nil, err
+		}
+
+		return copyOrder(o), nil
+	case futures.OrderTypeLimit:
+		price, err := e.GetPrice(ctx, req.Symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		o := e.newOrder(req)
+		fillPrice := e.applySlippage(price, o.Side)
+		marketable := isWithinLimit(o, fillPrice)
+
+		switch {
+		case marketable && req.TimeInForce == futures.TimeInForceTypeGTX:
+			o.Status = futures.OrderStatusTypeExpired
+			e.logger.Sugar().Infof("Paper %s post only order %d expired as it would fill at %s", o.Symbol, o.OrderID, fillPrice.String())
+		case marketable:
+			if err := e.fillOrder(o, fillPrice); err != nil {
+				return nil, err
+			}
+		case req.TimeInForce == futures.TimeInForceTypeIOC:
+			o.Status = futures.OrderStatusTypeExpired
+			e.logger.Sugar().Infof("Paper %s IOC order %d expired as it would fill at %s", o.Symbol, o.OrderID, fillPrice.String())
+		default:
+			e.logger.Sugar().Infof("Paper %s %s order %d held with limit price %s", o.Symbol, o.Type, o.OrderID, o.Price.String())
+		}
+
+		return copyOrder(o), nil
+	case futures.OrderTypeTakeProfitMarket, futures.OrderTypeStopMarket, futures.OrderTypeTrailingStopMarket:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		o := e.newOrder(req)
+		e.logger.Sugar().Infof("Paper %s %s order %d held with stop price %s", o.Symbol, o.Type, o.OrderID, o.StopPrice.String())
+
+		return copyOrder(o), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedOrder, req.Type)
+	}
+}
+
+func (e *PaperExchange) CancelOrder(ctx context.Context, symbol string, orderID int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, ok := e.orders[orderID]
+	if !ok || o.Symbol != symbol {
+		return errOrderNotFound
+	}
+
+	if o.Status != futures.OrderStatusTypeNew {
+		return errOrderNotOpen
+	}
+
+	o.Status = futures.OrderStatusTypeCanceled
+
+	return nil
+}
+
+func (e *PaperExchange) GetOrder(ctx context.Context, symbol string, orderID int64) (*Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, ok := e.orders[orderID]
+	if !ok || o.Symbol != symbol {
+		return nil, errOrderNotFound
+	}
+
+	return copyOrder(o), nil
+}
+
+func (e *PaperExchange) ListOpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	res := []*Order{}
+
+	for _, o := range e.orders {
+		if o.Symbol == symbol && o.Status == futures.OrderStatusTypeNew {
+			res = append(res, copyOrder(o))
+		}
+	}
+
+	// in the order they were placed, as the exchange lists them
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].OrderID < res[j].OrderID
+	})
+
+	return res, nil
+}
+
+func (e *PaperExchange) GetPositions(ctx context.Context) ([]*Position, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	res := make([]*Position, 0, len(e.positions))
+
+	for _, p := range e.positions {
+		pos := *p
+		if price, ok := e.lastPrices[p.Symbol]; ok {
+			pos.MarkPrice = price
+			pos.UnrealizedProfit = price.Sub(p.EntryPrice).Mul(p.Amount)
+		}
+
+		res = append(res, &pos)
+	}
+
+	return res, nil
+}
+
+// GetAvailableBalance returns the paper balance less the margin of the open positions, whatever the asset.
+func (e *PaperExchange) GetAvailableBalance(ctx context.Context, asset string) (decimal.Decimal, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.availableBalance(), nil
+}
+
+func (e *PaperExchange) GetRealizedPnL(ctx context.Context, since time.Time) (decimal.Decimal, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	res := decimal.Zero
+
+	for _, r := range e.realized {
+		if !r.at.Before(since) {
+			res = res.Add(r.pnl)
+		}
+	}
+
+	return res, nil
+}
+
+// UpdatePrice feeds a new price into the simulator and triggers any held order whose stop price is crossed.
+func (e *PaperExchange) UpdatePrice(symbol string, price decimal.Decimal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastPrices[symbol] = price
+
+	for _, o := range e.orders {
+		if o.Symbol != symbol || o.Status != futures.OrderStatusTypeNew || !e.isTriggered(o, price) {
+			continue
+		}
+
+		e.logger.Sugar().Infof("Paper %s %s order %d triggered at %s", o.Symbol, o.Type, o.OrderID, price.String())
+
+		fillPrice := e.applySlippage(price, o.Side)
+		if o.Type == futures.OrderTypeLimit {
+			fillPrice = o.Price
+		}
+
+		if err := e.fillOrder(o, fillPrice); err != nil {
+			e.logger.Error("Fail to fill triggered paper order", zap.Int64("orderID", o.OrderID), zap.Error(err))
+		}
+	}
+}
+
+// Run polls the market data exchange for symbols with held orders and feeds the prices into UpdatePrice.
+func (e *PaperExchange) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, symbol := range e.symbolsWithOpenOrders() {
+				price, err := e.marketData.GetPrice(ctx, symbol)
+				if err != nil {
+					e.logger.Error("Fail to get paper price feed", zap.String("symbol", symbol), zap.Error(err))
+
+					continue
+				}
+
+				e.UpdatePrice(symbol, price)
+			}
+		}
+	}
+}
+
+func (e *PaperExchange) PnL() PaperPnL {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	unrealizedPnL := decimal.Zero
+
+	for _, p := range e.positions {
+		if price, ok := e.lastPrices[p.Symbol]; ok {
+			unrealizedPnL = unrealizedPnL.Add(price.Sub(p.EntryPrice).Mul(p.Amount))
+		}
+	}
+
+	return PaperPnL{
+		Balance:       e.balance,
+		RealizedPnL:   e.realizedPnL,
+		UnrealizedPnL: unrealizedPnL,
+	}
+}
+
+func (e *PaperExchange) symbolsWithOpenOrders() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	seen := make(map[string]struct{})
+	res := []string{}
+
+	for _, o := range e.orders {
+		if _, ok := seen[o.Symbol]; ok || o.Status != futures.OrderStatusTypeNew {
+			continue
+		}
+
+		seen[o.Symbol] = struct{}{}
+		res = append(res, o.Symbol)
+	}
+
+	return res
+}
+
+func (e *PaperExchange) newOrder(req OrderRequest) *Order {
+	e.nextOrderID++
+	o := &Order{
+		Symbol:          req.Symbol,
+		OrderID:         e.nextOrderID,
+		Side:            req.Side,
+		Type:            req.Type,
+		Status:          futures.OrderStatusTypeNew,
+		Price:           req.Price,
+		StopPrice:       req.StopPrice,
+		OrigQuantity:    req.Quantity,
+		ActivationPrice: req.ActivationPrice,
+		CallbackRate:    req.CallbackRate,
+		ReduceOnly:      req.ReduceOnly,
+		ClosePosition:   req.ClosePosition,
+	}
+	e.orders[o.OrderID] = o
+
+	return o
+}
+
+// fillOrder must be called with e.mu held.
+func (e *PaperExchange) fillOrder(o *Order, fillPrice decimal.Decimal) error {
+	qty := o.OrigQuantity
+	posAmt := decimal.Zero
+
+	if p, ok := e.positions[o.Symbol]; ok {
+		posAmt = p.Amount
+	}
+
+	if o.ReduceOnly || o.ClosePosition {
+		closable := closableQuantity(posAmt, o.Side)
+		if o.ClosePosition || qty.GreaterThan(closable) {
+			qty = closable
+		}
+
+		if qty.IsZero() {
+			o.Status = futures.OrderStatusTypeExpired
+
+			return nil
+		}
+	}
+
+	delta := qty
+	if o.Side == futures.SideTypeSell {
+		delta = qty.Neg()
+	}
+
+	if !o.ReduceOnly && !o.ClosePosition && isIncreasing(posAmt, delta) {
+		leverage := e.leverage[o.Symbol]
+		if leverage <= 0 {
+			leverage = 1
+		}
+
+		requiredMargin := qty.Mul(fillPrice).Div(decimal.NewFromInt(int64(leverage)))
+		if requiredMargin.GreaterThan(e.availableBalance()) {
+			o.Status = futures.OrderStatusTypeRejected
+
+			return errInsufficientBalance
+		}
+	}
+
+	e.applyFill(o.Symbol, delta, fillPrice)
+
+	o.Status = futures.OrderStatusTypeFilled
+	o.ExecutedQuantity = qty
+	o.AvgPrice = fillPrice
+
+	e.logger.Sugar().Infof(
+		"Paper %s %s order %d filled %s at %s, balance %s, realized PnL %s",
+		o.Symbol, o.Side, o.OrderID, qty.String(), fillPrice.String(), e.balance.String(), e.realizedPnL.String(),
+	)
+
+	return nil
+}
+
+// applyFill must be called with e.mu held.
+func (e *PaperExchange) applyFill(symbol string, delta decimal.Decimal, price decimal.Decimal) {
+	p, ok := e.positions[symbol]
+	if !ok {
+		p = &Position{Symbol: symbol}
+		e.positions[symbol] = p
+	}
+
+	if isIncreasing(p.Amount, delta) {
+		notional := p.Amount.Abs().Mul(p.EntryPrice).Add(delta.Abs().Mul(price))
+		p.Amount = p.Amount.Add(delta)
+		p.EntryPrice = notional.Div(p.Amount.Abs())
+
+		return
+	}
+
+	closedQty := decimal.Min(delta.Abs(), p.Amount.Abs())
+	pnl := price.Sub(p.EntryPrice).Mul(closedQty)
+
+	if p.Amount.IsNegative() {
+		pnl = pnl.Neg()
+	}
+
+	e.realizedPnL = e.realizedPnL.Add(pnl)
+	e.realized = append(e.realized, paperRealizedPnL{at: time.Now(), pnl: pnl})
+	e.balance = e.balance.Add(pnl)
+
+	newAmount := p.Amount.Add(delta)
+
+	switch {
+	case newAmount.IsZero():
+		delete(e.positions, symbol)
+	case newAmount.Sign() != p.Amount.Sign():
+		p.Amount = newAmount
+		p.EntryPrice = price
+	default:
+		p.Amount = newAmount
+	}
+}
+
+// availableBalance must be called with e.mu held.
+func (e *PaperExchange) availableBalance() decimal.Decimal {
+	usedMargin := decimal.Zero
+
+	for _, p := range e.positions {
+		leverage := e.leverage[p.Symbol]
+		if leverage <= 0 {
+			leverage = 1
+		}
+
+		usedMargin = usedMargin.Add(p.Amount.Abs().Mul(p.EntryPrice).Div(decimal.NewFromInt(int64(leverage))))
+	}
+
+	return e.balance.Sub(usedMargin)
+}
+
+func (e *PaperExchange) applySlippage(price decimal.Decimal, side futures.SideType) decimal.Decimal {
+	slippage := decimal.NewFromFloat(e.paperOpts.slippagePercentage).
+		Div(decimal.NewFromInt(100)) // nolint: gomnd
+
+	if side == futures.SideTypeSell {
+		return price.Mul(decimal.NewFromInt(1).Sub(slippage))
+	}
+
+	return price.Mul(decimal.NewFromInt(1).Add(slippage))
+}
+
+// isTriggered must be called with e.mu held.
+func (e *PaperExchange) isTriggered(o *Order, price decimal.Decimal) bool {
+	switch o.Type {
+	case futures.OrderTypeTakeProfitMarket:
+		if o.Side == futures.SideTypeSell {
+			return price.GreaterThanOrEqual(o.StopPrice)
+		}
+
+		return price.LessThanOrEqual(o.StopPrice)
+	case futures.OrderTypeStopMarket:
+		if o.Side == futures.SideTypeSell {
+			return price.LessThanOrEqual(o.StopPrice)
+		}
+
+		return price.GreaterThanOrEqual(o.StopPrice)
+	case futures.OrderTypeTrailingStopMarket:
+		return e.isTrailingStopTriggered(o, price)
+	case futures.OrderTypeLimit:
+		return isWithinLimit(o, price)
+	default:
+		return false
+	}
+}
+
+// isTrailingStopTriggered must be called with e.mu held.
+func (e *PaperExchange) isTrailingStopTriggered(o *Order, price decimal.Decimal) bool {
+	isSell := o.Side == futures.SideTypeSell
+
+	extreme, activated := e.trailingExtremes[o.OrderID]
+	if !activated {
+		if !o.ActivationPrice.IsZero() &&
+			((isSell && price.LessThan(o.ActivationPrice)) || (!isSell && price.GreaterThan(o.ActivationPrice))) {
+			return false
+		}
+
+		extreme = price
+	}
+
+	if (isSell && price.GreaterThan(extreme)) || (!isSell && price.LessThan(extreme)) {
+		extreme = price
+	}
+
+	e.trailingExtremes[o.OrderID] = extreme
+
+	callback := o.CallbackRate.Div(decimal.NewFromInt(100)) // nolint: gomnd
+	if isSell {
+		return price.LessThanOrEqual(extreme.Mul(decimal.NewFromInt(1).Sub(callback)))
+	}
+
+	return price.GreaterThanOrEqual(extreme.Mul(decimal.NewFromInt(1).Add(callback)))
+}
+
+func isIncreasing(posAmt decimal.Decimal, delta decimal.Decimal) bool {
+	return posAmt.IsZero() || posAmt.Sign() == delta.Sign()
+}
+
+func closableQuantity(posAmt decimal.Decimal, side futures.SideType) decimal.Decimal {
+	if (side == futures.SideTypeSell && posAmt.IsPositive()) ||
+		(side == futures.SideTypeBuy && posAmt.IsNegative()) {
+		return posAmt.Abs()
+	}
+
+	return decimal.Zero
+}
+
+func copyOrder(o *Order) *Order {
+	res := *o
+
+	return &res
+}
+
+// isWithinLimit returns whether the limit order can be filled at the price.
+func isWithinLimit(o *Order, price decimal.Decimal) bool {
+	if o.Side == futures.SideTypeSell {
+		return price.GreaterThanOrEqual(o.Price)
+	}
+
+	return price.LessThanOrEqual(o.Price)
+}
@@ -0,0 +1,198 @@
+package trading
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPaperExchangeTakeProfit(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperInitialBalanceInUSD(1000),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	positions, err := paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "2.5", positions[0].Amount.String())
+	assert.Equal(t, "40", positions[0].EntryPrice.String())
+
+	openOrders, err := paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 1)
+	assert.Equal(t, futures.OrderTypeTakeProfitMarket, openOrders[0].Type)
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(41))
+	assert.Equal(t, "2.5", paper.PnL().UnrealizedPnL.String())
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(42))
+
+	o, err := paper.GetOrder(context.Background(), "SOLUSDT", openOrders[0].OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, futures.OrderStatusTypeFilled, o.Status)
+	assert.Equal(t, "2.5", o.ExecutedQuantity.String())
+
+	positions, err = paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+
+	pnl := paper.PnL()
+	assert.Equal(t, "5", pnl.RealizedPnL.String())
+	assert.Equal(t, "1005", pnl.Balance.String())
+	assert.True(t, pnl.UnrealizedPnL.IsZero())
+}
+
+func TestPaperExchangeSlippage(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(100)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(1),
+	)
+
+	buyOrder, err := paper.CreateOrder(context.Background(), OrderRequest{
+		Symbol:   "SOLUSDT",
+		Side:     futures.SideTypeBuy,
+		Type:     futures.OrderTypeMarket,
+		Quantity: decimal.NewFromInt(2),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "101", buyOrder.AvgPrice.String())
+
+	sellOrder, err := paper.CreateOrder(context.Background(), OrderRequest{
+		Symbol:     "SOLUSDT",
+		Side:       futures.SideTypeSell,
+		Type:       futures.OrderTypeMarket,
+		Quantity:   decimal.NewFromInt(5),
+		ReduceOnly: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "99", sellOrder.AvgPrice.String())
+	assert.Equal(t, "2", sellOrder.ExecutedQuantity.String())
+	assert.Equal(t, "-4", paper.PnL().RealizedPnL.String())
+}
+
+func TestPaperExchangeInsufficientBalance(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(100)),
+		zap.NewNop(),
+		WithPaperInitialBalanceInUSD(100),
+	)
+	require.NoError(t, paper.ChangeLeverage(context.Background(), "SOLUSDT", 2))
+
+	_, err := paper.CreateOrder(context.Background(), OrderRequest{
+		Symbol:   "SOLUSDT",
+		Side:     futures.SideTypeBuy,
+		Type:     futures.OrderTypeMarket,
+		Quantity: decimal.NewFromInt(3),
+	})
+	assert.ErrorIs(t, err, errInsufficientBalance)
+}
+
+func TestPaperExchangeTrailingStop(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(100)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithExitMode(ExitModeTrailingStop),
+		WithTrailingStopCallbackRate(2),
+		WithTrailingStopActivationPriceChangedPercentage(5),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	openOrders, err := paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 1)
+	assert.Equal(t, futures.OrderTypeTrailingStopMarket, openOrders[0].Type)
+	assert.Equal(t, "105", openOrders[0].ActivationPrice.String())
+	assert.Equal(t, "1", openOrders[0].OrigQuantity.String())
+
+	for _, p := range []int64{97, 104, 110, 120, 118} {
+		paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(p))
+	}
+
+	o, err := paper.GetOrder(context.Background(), "SOLUSDT", openOrders[0].OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, futures.OrderStatusTypeNew, o.Status)
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromFloat(117.6))
+
+	o, err = paper.GetOrder(context.Background(), "SOLUSDT", openOrders[0].OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, futures.OrderStatusTypeFilled, o.Status)
+	assert.Equal(t, "17.6", paper.PnL().RealizedPnL.String())
+}
+
+func TestPaperExchangeLimitOrder(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(100)),
+		zap.NewNop(),
+		WithPaperInitialBalanceInUSD(1000),
+		WithPaperSlippagePercentage(1),
+	)
+
+	newLimitOrder := func(price int64, timeInForce futures.TimeInForceType) *Order {
+		o, err := paper.CreateOrder(context.Background(), OrderRequest{
+			Symbol:      "SOLUSDT",
+			Side:        futures.SideTypeBuy,
+			Type:        futures.OrderTypeLimit,
+			TimeInForce: timeInForce,
+			Quantity:    decimal.NewFromInt(1),
+			Price:       decimal.NewFromInt(price),
+		})
+		require.NoError(t, err)
+
+		return o
+	}
+
+	o := newLimitOrder(102, futures.TimeInForceTypeIOC)
+	assert.Equal(t, futures.OrderStatusTypeFilled, o.Status)
+	assert.Equal(t, "101", o.AvgPrice.String())
+
+	o = newLimitOrder(100, futures.TimeInForceTypeIOC)
+	assert.Equal(t, futures.OrderStatusTypeExpired, o.Status, "the slippage is beyond the limit")
+	assert.True(t, o.ExecutedQuantity.IsZero())
+
+	o = newLimitOrder(102, futures.TimeInForceTypeGTX)
+	assert.Equal(t, futures.OrderStatusTypeExpired, o.Status, "a post only order would fill at once")
+
+	o = newLimitOrder(95, futures.TimeInForceTypeGTX)
+	assert.Equal(t, futures.OrderStatusTypeNew, o.Status)
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(94))
+
+	o, err := paper.GetOrder(context.Background(), "SOLUSDT", o.OrderID)
+	require.NoError(t, err)
+	assert.Equal(t, futures.OrderStatusTypeFilled, o.Status)
+	assert.Equal(t, "95", o.AvgPrice.String(), "a held limit order fills at its limit")
+
+	positions, err := paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "2", positions[0].Amount.String())
+}
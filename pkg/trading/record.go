@@ -0,0 +1,126 @@
+package trading
+
+import (
+	"context"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/pkg/journal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+func (m *BinanceFuturesManager) createOrder(ctx context.Context, kind journal.OrderKind, req OrderRequest) (*Order, error) {
+	o, err := m.exchange.CreateOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	m.recordOrder(kind, o)
+
+	return o, nil
+}
+
+func (m *BinanceFuturesManager) cancelOrder(ctx context.Context, kind journal.OrderKind, symbol string, orderID int64) error {
+	if err := m.exchange.CancelOrder(ctx, symbol, orderID); err != nil {
+		return err
+	}
+
+	if m.futuresOpts.journal == nil {
+		return nil
+	}
+
+	r, err := m.futuresOpts.journal.GetOrder(symbol, orderID)
+	if err != nil {
+		r = journal.OrderRecord{
+			Symbol:    symbol,
+			OrderID:   orderID,
+			Kind:      kind,
+			CreatedAt: m.now(),
+		}
+	}
+
+	r.Status = string(futures.OrderStatusTypeCanceled)
+	r.UpdatedAt = m.now()
+
+	if err := m.futuresOpts.journal.RecordOrder(r); err != nil {
+		m.logger.Error("Fail to record cancelled order", zap.String("symbol", symbol), zap.Int64("orderID", orderID), zap.Error(err))
+	}
+
+	return nil
+}
+
+// recordOrder writes the order, and its fill once it has been filled, into the journal.
+// Journal errors are logged only so that they never block trading.
+func (m *BinanceFuturesManager) recordOrder(kind journal.OrderKind, o *Order) {
+	if m.futuresOpts.journal == nil {
+		return
+	}
+
+	now := m.now()
+
+	err := m.futuresOpts.journal.RecordOrder(journal.OrderRecord{
+		Symbol:           o.Symbol,
+		OrderID:          o.OrderID,
+		Kind:             kind,
+		Side:             string(o.Side),
+		Type:             string(o.Type),
+		Status:           string(o.Status),
+		Quantity:         o.OrigQuantity.String(),
+		Price:            o.Price.String(),
+		StopPrice:        o.StopPrice.String(),
+		ExecutedQuantity: o.ExecutedQuantity.String(),
+		AvgPrice:         o.AvgPrice.String(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	})
+	if err != nil {
+		m.logger.Error("Fail to record order", zap.String("symbol", o.Symbol), zap.Int64("orderID", o.OrderID), zap.Error(err))
+
+		return
+	}
+
+	if !hasFills(o.Status, o.ExecutedQuantity) || m.isFillRecorded(o.Symbol, o.OrderID) {
+		return
+	}
+
+	err = m.futuresOpts.journal.RecordFill(journal.FillRecord{
+		Symbol:   o.Symbol,
+		OrderID:  o.OrderID,
+		Side:     string(o.Side),
+		Quantity: o.ExecutedQuantity.String(),
+		Price:    o.AvgPrice.String(),
+		FilledAt: now,
+	})
+	if err != nil {
+		m.logger.Error("Fail to record fill", zap.String("symbol", o.Symbol), zap.Int64("orderID", o.OrderID), zap.Error(err))
+	}
+}
+
+// isFillRecorded reports whether the fill of the order is already in the journal, a journal error is logged
+// and treated as recorded so that a fill is never duplicated.
+func (m *BinanceFuturesManager) isFillRecorded(symbol string, orderID int64) bool {
+	fills, err := m.futuresOpts.journal.ListFills(symbol)
+	if err != nil {
+		m.logger.Error("Fail to list fills", zap.String("symbol", symbol), zap.Int64("orderID", orderID), zap.Error(err))
+
+		return true
+	}
+
+	for _, f := range fills {
+		if f.OrderID == orderID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasFills reports whether the order is done with a non-zero executed quantity. Besides a filled order, it
+// covers a limit_ioc entry expiring or a limit_gtx entry cancelled after a partial fill.
+func hasFills(status futures.OrderStatusType, executedQty decimal.Decimal) bool {
+	if status == futures.OrderStatusTypeNew || status == futures.OrderStatusTypePartiallyFilled {
+		return false
+	}
+
+	return executedQty.IsPositive()
+}
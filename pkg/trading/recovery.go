@@ -0,0 +1,230 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/pkg/journal"
+	"go.uber.org/zap"
+)
+
+type ReconcileReport struct {
+	// positions with exit orders still open on the exchange, now tracked again
+	Resumed []string
+	// positions whose exit orders were missing and have been placed again
+	Protected []string
+	// positions that cannot be matched with an entry order in the journal, left untouched
+	Orphaned []string
+	// positions whose exit orders could not be placed again
+	Failed []string
+	// positions whose exit orders are missing, left untouched as orders are not executed
+	Unprotected []string
+	// positions without exit orders that cannot be checked against the journal as none is configured, left untouched
+	Unverified []string
+}
+
+// Reconcile compares the open positions and orders on the exchange with the journal after a restart.
+// Exit orders that are still open are tracked again, missing exit orders are placed again from the
+// position's entry price, and positions without a journaled entry order are reported as orphaned.
+// Nothing is placed or cancelled if orders are not executed, the positions are only reported.
+func (m *BinanceFuturesManager) Reconcile() (ReconcileReport, error) {
+	ctx := context.Background()
+	report := ReconcileReport{}
+
+	positions, err := m.exchange.GetPositions(ctx)
+	if err != nil {
+		return report, fmt.Errorf("get positions: %w", err)
+	}
+
+	openSymbols := make(map[string]struct{}, len(positions))
+
+	for _, p := range positions {
+		openSymbols[p.Symbol] = struct{}{}
+
+		openOrders, err := m.exchange.ListOpenOrders(ctx, p.Symbol)
+		if err != nil {
+			return report, fmt.Errorf("list open orders: %w", err)
+		}
+
+		exitOrderIDs := []int64{}
+
+		for _, o := range openOrders {
+			if isExitOrder(o) {
+				exitOrderIDs = append(exitOrderIDs, o.OrderID)
+			}
+		}
+
+		entryOrder, err := m.findEntryOrder(p.Symbol)
+		if err != nil {
+			return report, err
+		}
+
+		switch {
+		case m.futuresOpts.journal == nil && len(exitOrderIDs) == 0:
+			m.logger.Warn(
+				"Position without exit orders cannot be checked as no journal is configured",
+				zap.String("symbol", p.Symbol),
+				zap.String("amount", p.Amount.String()),
+			)
+
+			report.Unverified = append(report.Unverified, p.Symbol)
+		case m.futuresOpts.journal != nil && entryOrder == nil:
+			m.logger.Warn(
+				"Orphaned position without journaled entry order",
+				zap.String("symbol", p.Symbol),
+				zap.String("amount", p.Amount.String()),
+				zap.Int("exitOrders", len(exitOrderIDs)),
+			)
+
+			report.Orphaned = append(report.Orphaned, p.Symbol)
+		case len(exitOrderIDs) > 0:
+			m.trackExitOrders(p.Symbol, exitOrderIDs)
+			m.logger.Info("Resumed tracking exit orders", zap.String("symbol", p.Symbol), zap.Int64s("orderIDs", exitOrderIDs))
+
+			report.Resumed = append(report.Resumed, p.Symbol)
+		case !m.futuresOpts.willExecuteOrder:
+			m.logger.Warn("Position is missing exit orders", zap.String("symbol", p.Symbol), zap.String("amount", p.Amount.String()))
+
+			report.Unprotected = append(report.Unprotected, p.Symbol)
+		default:
+			if err := m.protectPosition(ctx, p); err != nil {
+				m.logger.Error("Fail to place missing exit orders", zap.String("symbol", p.Symbol), zap.Error(err))
+
+				report.Failed = append(report.Failed, p.Symbol)
+
+				continue
+			}
+
+			m.logger.Info("Placed missing exit orders", zap.String("symbol", p.Symbol), zap.String("amount", p.Amount.String()))
+
+			report.Protected = append(report.Protected, p.Symbol)
+		}
+
+		if entryOrder != nil {
+			m.resumeDeadline(p.Symbol, *entryOrder)
+		}
+	}
+
+	if err := m.reconcileJournaledExitOrders(ctx, openSymbols); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+func (m *BinanceFuturesManager) protectPosition(ctx context.Context, p *Position) error {
+	side := futures.SideTypeBuy
+	if p.Amount.IsNegative() {
+		side = futures.SideTypeSell
+	}
+
+	futuresSymbol, err := m.getSymbol(p.Symbol)
+	if err != nil {
+		return err
+	}
+
+	return m.placeExitOrders(ctx, futuresSymbol, &Order{
+		Symbol:           p.Symbol,
+		Side:             side,
+		Status:           futures.OrderStatusTypeFilled,
+		ExecutedQuantity: p.Amount.Abs(),
+		AvgPrice:         p.EntryPrice,
+	})
+}
+
+// findEntryOrder returns the latest filled, fully or partly, entry order of the symbol in the journal, nil if there is none.
+func (m *BinanceFuturesManager) findEntryOrder(symbol string) (*journal.OrderRecord, error) {
+	if m.futuresOpts.journal == nil {
+		return nil, nil
+	}
+
+	orders, err := m.futuresOpts.journal.ListOrders(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("list journaled orders: %w", err)
+	}
+
+	for i := len(orders) - 1; i >= 0; i-- {
+		if orders[i].Kind != journal.OrderKindEntry {
+			continue
+		}
+
+		executedQty, err := parseDecimal(orders[i].ExecutedQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("parse executed quantity of order %d: %w", orders[i].OrderID, err)
+		}
+
+		if hasFills(futures.OrderStatusType(orders[i].Status), executedQty) {
+			return &orders[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *BinanceFuturesManager) resumeDeadline(symbol string, entryOrder journal.OrderRecord) {
+	if m.futuresOpts.maxHoldingDuration <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.deadlines[symbol]; ok {
+		return
+	}
+
+	m.deadlines[symbol] = entryOrder.CreatedAt.Add(m.futuresOpts.maxHoldingDuration)
+}
+
+// reconcileJournaledExitOrders refreshes the status of exit orders the journal still considers open,
+// and cancels those left behind by a position that no longer exists.
+func (m *BinanceFuturesManager) reconcileJournaledExitOrders(ctx context.Context, openSymbols map[string]struct{}) error {
+	if m.futuresOpts.journal == nil {
+		return nil
+	}
+
+	orders, err := m.futuresOpts.journal.ListOrders("")
+	if err != nil {
+		return fmt.Errorf("list journaled orders: %w", err)
+	}
+
+	for _, r := range orders {
+		if r.Kind != journal.OrderKindExit ||
+			(r.Status != string(futures.OrderStatusTypeNew) && r.Status != string(futures.OrderStatusTypePartiallyFilled)) {
+			continue
+		}
+
+		o, err := m.exchange.GetOrder(ctx, r.Symbol, r.OrderID)
+		if err != nil {
+			m.logger.Error("Fail to get journaled exit order", zap.String("symbol", r.Symbol), zap.Int64("orderID", r.OrderID), zap.Error(err))
+
+			continue
+		}
+
+		if o.Status == futures.OrderStatusTypeNew || o.Status == futures.OrderStatusTypePartiallyFilled {
+			if _, ok := openSymbols[r.Symbol]; ok {
+				continue
+			}
+
+			if !m.futuresOpts.willExecuteOrder {
+				m.logger.Warn("Exit order of closed position is still open", zap.String("symbol", r.Symbol), zap.Int64("orderID", r.OrderID))
+
+				continue
+			}
+
+			if err := m.cancelOrder(ctx, journal.OrderKindExit, r.Symbol, r.OrderID); err != nil && !errors.Is(err, errOrderNotOpen) {
+				return fmt.Errorf("cancel stale exit order: %w", err)
+			}
+
+			m.logger.Info("Cancelled exit order of closed position", zap.String("symbol", r.Symbol), zap.Int64("orderID", r.OrderID))
+
+			continue
+		}
+
+		m.recordOrder(journal.OrderKindExit, o)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package trading
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/pkg/journal"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.db"))
+	require.NoError(t, err)
+
+	defer func() {
+		_ = j.Close()
+	}()
+
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(0),
+	)
+	opts := []FuturesOption{
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithStopLossPriceChangedPercentage(2.5),
+		WithJournal(j),
+	}
+
+	m, err := NewBinanceFuturesManager(paper, zap.NewNop(), opts...)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	// the daemon died before its exit orders were placed
+	openOrders, err := paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+
+	for _, o := range openOrders {
+		require.NoError(t, paper.CancelOrder(ctx, "SOLUSDT", o.OrderID))
+	}
+
+	// a position opened by hand is not in the journal
+	_, err = paper.CreateOrder(ctx, OrderRequest{
+		Symbol:   "ETHUSDT",
+		Side:     futures.SideTypeBuy,
+		Type:     futures.OrderTypeMarket,
+		Quantity: decimal.NewFromInt(1),
+	})
+	require.NoError(t, err)
+
+	restarted, err := NewBinanceFuturesManager(paper, zap.NewNop(), opts...)
+	require.NoError(t, err)
+
+	report, err := restarted.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SOLUSDT"}, report.Protected)
+	assert.Equal(t, []string{"ETHUSDT"}, report.Orphaned)
+	assert.Empty(t, report.Resumed)
+	assert.Empty(t, report.Failed)
+
+	openOrders, err = paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 2)
+
+	stopPrices := map[futures.OrderType]string{}
+	for _, o := range openOrders {
+		stopPrices[o.Type] = o.StopPrice.String()
+	}
+
+	assert.Equal(t, "42", stopPrices[futures.OrderTypeTakeProfitMarket])
+	assert.Equal(t, "39", stopPrices[futures.OrderTypeStopMarket])
+
+	journaledOrders, err := j.ListOrders("SOLUSDT")
+	require.NoError(t, err)
+
+	statuses := []string{}
+	for _, o := range journaledOrders {
+		statuses = append(statuses, o.Type+" "+o.Status)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"MARKET FILLED",
+		"TAKE_PROFIT_MARKET CANCELED",
+		"STOP_MARKET CANCELED",
+		"TAKE_PROFIT_MARKET NEW",
+		"STOP_MARKET NEW",
+	}, statuses)
+
+	openOrders, err = paper.ListOpenOrders(ctx, "ETHUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders)
+
+	restartedAgain, err := NewBinanceFuturesManager(paper, zap.NewNop(), opts...)
+	require.NoError(t, err)
+
+	report, err = restartedAgain.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SOLUSDT"}, report.Resumed)
+	assert.Len(t, restartedAgain.getExitOrders()["SOLUSDT"], 2)
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(42))
+	require.NoError(t, restartedAgain.CheckExitOrders())
+
+	openOrders, err = paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders)
+}
+
+func TestReconcilePartlyFilledEntry(t *testing.T) {
+	ctx := context.Background()
+
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.db"))
+	require.NoError(t, err)
+
+	defer func() {
+		_ = j.Close()
+	}()
+
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+	exchange.limitFills = []decimal.Decimal{decimal.NewFromInt(1)}
+	opts := []FuturesOption{
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithEntryMode(EntryModeLimitIOC),
+		WithStopLossPriceChangedPercentage(2.5),
+		WithJournal(j),
+	}
+
+	m, err := NewBinanceFuturesManager(exchange, zap.NewNop(), opts...)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	entryOrder := exchange.orders[0]
+	require.Equal(t, futures.OrderStatusTypeExpired, entryOrder.Status)
+	require.Equal(t, "1", entryOrder.ExecutedQuantity.String())
+
+	fills, err := j.ListFills("SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, fills, 1)
+	assert.Equal(t, entryOrder.OrderID, fills[0].OrderID)
+	assert.Equal(t, "1", fills[0].Quantity)
+
+	// the daemon died before its exit orders were placed
+	openOrders, err := exchange.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+
+	for _, o := range openOrders {
+		require.NoError(t, exchange.CancelOrder(ctx, "SOLUSDT", o.OrderID))
+	}
+
+	exchange.positions = []*Position{{Symbol: "SOLUSDT", Amount: decimal.NewFromInt(1), EntryPrice: decimal.NewFromInt(40)}}
+
+	restarted, err := NewBinanceFuturesManager(exchange, zap.NewNop(), opts...)
+	require.NoError(t, err)
+
+	report, err := restarted.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SOLUSDT"}, report.Protected)
+	assert.Empty(t, report.Orphaned)
+
+	openOrders, err = exchange.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 2)
+	assert.Len(t, restarted.getExitOrders()["SOLUSDT"], 2)
+
+	// the close position exit orders are told apart by their type, binance does not return the flag
+	restartedAgain, err := NewBinanceFuturesManager(exchange, zap.NewNop(), opts...)
+	require.NoError(t, err)
+
+	report, err = restartedAgain.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SOLUSDT"}, report.Resumed)
+	assert.Len(t, restartedAgain.getExitOrders()["SOLUSDT"], 2)
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.db"))
+	require.NoError(t, err)
+
+	defer func() {
+		_ = j.Close()
+	}()
+
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(paper, zap.NewNop(), WithWillExecuteOrder(true), WithEachTradeAmountInUSD(100), WithJournal(j))
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	openOrders, err := paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+
+	for _, o := range openOrders {
+		require.NoError(t, paper.CancelOrder(ctx, "SOLUSDT", o.OrderID))
+	}
+
+	_, err = paper.CreateOrder(ctx, OrderRequest{
+		Symbol:   "ETHUSDT",
+		Side:     futures.SideTypeBuy,
+		Type:     futures.OrderTypeMarket,
+		Quantity: decimal.NewFromInt(1),
+	})
+	require.NoError(t, err)
+
+	dryRun, err := NewBinanceFuturesManager(paper, zap.NewNop(), WithJournal(j))
+	require.NoError(t, err)
+
+	report, err := dryRun.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SOLUSDT"}, report.Unprotected)
+	assert.Equal(t, []string{"ETHUSDT"}, report.Orphaned)
+	assert.Empty(t, report.Protected)
+
+	openOrders, err = paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders, "no exit order is placed in dry run")
+
+	withoutJournal, err := NewBinanceFuturesManager(paper, zap.NewNop(), WithWillExecuteOrder(true))
+	require.NoError(t, err)
+
+	report, err = withoutJournal.Reconcile()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"SOLUSDT", "ETHUSDT"}, report.Unverified)
+	assert.Empty(t, report.Orphaned)
+	assert.Empty(t, report.Protected)
+}
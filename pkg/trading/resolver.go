@@ -0,0 +1,78 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+const symbolStatusTrading = "TRADING"
+
+var errSymbolNotTrading = errors.New("futures symbol not trading")
+
+// contractMultipliers are the prefixes of the contracts of low priced coins, e.g. 1000SHIBUSDT is
+// quoted and traded in units of 1000 SHIB.
+// nolint: gochecknoglobals
+var contractMultipliers = []struct {
+	prefix     string
+	multiplier int64
+}{
+	{prefix: "", multiplier: 1},
+	{prefix: "1000", multiplier: 1000},
+	{prefix: "10000", multiplier: 10000},
+	{prefix: "1000000", multiplier: 1000000},
+	{prefix: "1M", multiplier: 1000000},
+}
+
+// ResolvedSymbol is the futures contract the coin of a signal is traded with.
+type ResolvedSymbol struct {
+	futures.Symbol
+	// Multiplier is the number of coins in a unit of the contract, e.g. 1000 for 1000SHIBUSDT. The contract
+	// price is quoted per unit of the contract as well, so a notional amount needs no adjustment.
+	Multiplier decimal.Decimal
+}
+
+// resolveSymbol finds the contract of the coin, trying the quote assets in the order of preference and,
+// for each of them, the plain contract before the multiplier prefixed ones. Only a contract in TRADING
+// status is picked.
+func resolveSymbol(symbols map[string]futures.Symbol, coin string, quoteAssets []string) (ResolvedSymbol, error) {
+	coin = strings.ToUpper(coin)
+	notTrading := []string{}
+
+	for _, quoteAsset := range quoteAssets {
+		for _, c := range contractMultipliers {
+			s, ok := symbols[c.prefix+coin+quoteAsset]
+			if !ok {
+				continue
+			}
+
+			if s.Status != symbolStatusTrading {
+				notTrading = append(notTrading, s.Symbol+" "+s.Status)
+
+				continue
+			}
+
+			return ResolvedSymbol{
+				Symbol:     s,
+				Multiplier: decimal.NewFromInt(c.multiplier),
+			}, nil
+		}
+	}
+
+	if len(notTrading) > 0 {
+		return ResolvedSymbol{}, fmt.Errorf("%w: %s", errSymbolNotTrading, strings.Join(notTrading, ", "))
+	}
+
+	return ResolvedSymbol{}, fmt.Errorf("%w: %s", errSymbolNotFound, coin)
+}
+
+// ResolveSymbol finds the contract of the coin among the supported symbols.
+func (m *BinanceFuturesManager) ResolveSymbol(coin string) (ResolvedSymbol, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return resolveSymbol(m.supportedSymbols, coin, m.futuresOpts.quoteAssets)
+}
@@ -0,0 +1,95 @@
+package trading
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/api"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestResolveSymbol(t *testing.T) {
+	symbols := map[string]futures.Symbol{
+		"SOLUSDT":      {Symbol: "SOLUSDT", Status: "TRADING", BaseAsset: "SOL", QuoteAsset: "USDT"},
+		"SOLBUSD":      {Symbol: "SOLBUSD", Status: "TRADING", BaseAsset: "SOL", QuoteAsset: "BUSD"},
+		"1000SHIBUSDT": {Symbol: "1000SHIBUSDT", Status: "TRADING", BaseAsset: "1000SHIB", QuoteAsset: "USDT"},
+		"1MBABYDOGEUSDT": {
+			Symbol: "1MBABYDOGEUSDT", Status: "TRADING", BaseAsset: "1MBABYDOGE", QuoteAsset: "USDT",
+		},
+		"ARBBUSD":      {Symbol: "ARBBUSD", Status: "TRADING", BaseAsset: "ARB", QuoteAsset: "BUSD"},
+		"SUIUSDT":      {Symbol: "SUIUSDT", Status: "PENDING_TRADING", BaseAsset: "SUI", QuoteAsset: "USDT"},
+		"SUIBUSD":      {Symbol: "SUIBUSD", Status: "TRADING", BaseAsset: "SUI", QuoteAsset: "BUSD"},
+		"LUNAUSDT":     {Symbol: "LUNAUSDT", Status: "SETTLING", BaseAsset: "LUNA", QuoteAsset: "USDT"},
+		"BTCUSDT_0331": {Symbol: "BTCUSDT_0331", Status: "TRADING", BaseAsset: "BTC", QuoteAsset: "USDT"},
+	}
+
+	testCases := []struct {
+		coin        string
+		quoteAssets []string
+		symbol      string
+		multiplier  int64
+		err         error
+	}{
+		{coin: "SOL", quoteAssets: []string{"USDT", "BUSD"}, symbol: "SOLUSDT", multiplier: 1},
+		{coin: "sol", quoteAssets: []string{"BUSD", "USDT"}, symbol: "SOLBUSD", multiplier: 1},
+		{coin: "SHIB", quoteAssets: []string{"USDT", "BUSD"}, symbol: "1000SHIBUSDT", multiplier: 1000},
+		{coin: "BABYDOGE", quoteAssets: []string{"USDT"}, symbol: "1MBABYDOGEUSDT", multiplier: 1000000},
+		{coin: "ARB", quoteAssets: []string{"USDT", "BUSD"}, symbol: "ARBBUSD", multiplier: 1},
+		{coin: "ARB", quoteAssets: []string{"USDT"}, err: errSymbolNotFound},
+		{coin: "SUI", quoteAssets: []string{"USDT", "BUSD"}, symbol: "SUIBUSD", multiplier: 1},
+		{coin: "LUNA", quoteAssets: []string{"USDT", "BUSD"}, err: errSymbolNotTrading},
+		{coin: "BTC", quoteAssets: []string{"USDT"}, err: errSymbolNotFound},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			s, err := resolveSymbol(symbols, tc.coin, tc.quoteAssets)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.symbol, s.Symbol.Symbol)
+			assert.Equal(t, decimal.NewFromInt(tc.multiplier).String(), s.Multiplier.String())
+		})
+	}
+}
+
+func TestConsumeBuySignalOfMultiplierContract(t *testing.T) {
+	exchange := newFakeExchange(decimal.NewFromFloat(0.01))
+	exchange.symbols["1000SHIBUSDT"] = futures.Symbol{
+		Symbol:            "1000SHIBUSDT",
+		Status:            "TRADING",
+		BaseAsset:         "1000SHIB",
+		QuoteAsset:        "USDT",
+		QuantityPrecision: 0,
+		Filters: []map[string]interface{}{
+			{
+				"filterType": string(futures.SymbolFilterTypePrice),
+				"tickSize":   "0.000001",
+			},
+		},
+	}
+
+	m, err := NewBinanceFuturesManager(
+		exchange,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, m.ConsumeBuySignal(api.BuySignal{Symbol: "SHIB"}))
+
+	require.NotEmpty(t, exchange.orders)
+	assert.Equal(t, "1000SHIBUSDT", exchange.orders[0].Symbol)
+	assert.Equal(t, "10000", exchange.orders[0].OrigQuantity.String())
+}
@@ -0,0 +1,299 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// RiskManager guards the entries of a BinanceFuturesManager with account level limits. A signal that would
+// open a position, long or short, and breaks a limit is logged and skipped. A sell signal always goes through
+// when it closes a long position.
+type RiskManager struct {
+	manager  *BinanceFuturesManager
+	riskOpts riskOptions
+	logger   *zap.Logger
+
+	mu          sync.Mutex
+	lastEntries map[string]time.Time
+	killReason  string
+	// when the kill switch engaged by the daily loss limit disengages, zero if it is engaged by Kill
+	killUntil time.Time
+
+	now func() time.Time
+}
+
+func NewRiskManager(manager *BinanceFuturesManager, logger *zap.Logger, opts ...RiskOption) *RiskManager {
+	options := newDefaultRiskOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &RiskManager{
+		manager:     manager,
+		riskOpts:    options,
+		logger:      logger,
+		lastEntries: make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// ConsumeSignal consumes the signal as a sell signal if its side is sell, otherwise as a buy signal.
+func (r *RiskManager) ConsumeSignal(signal api.BuySignal) error {
+	if signal.IsSell() {
+		return r.ConsumeSellSignal(signal)
+	}
+
+	return r.ConsumeBuySignal(signal)
+}
+
+// ConsumeBuySignal passes the signal to the manager if no limit is broken.
+func (r *RiskManager) ConsumeBuySignal(buySignal api.BuySignal) error {
+	reason, err := r.checkEntry(context.Background(), buySignal)
+	if err != nil {
+		return err
+	}
+
+	if reason != "" {
+		r.logger.Warn("Block buy signal", zap.String("symbol", buySignal.Symbol), zap.String("reason", reason))
+
+		return nil
+	}
+
+	entryOrder, err := r.manager.consumeBuySignal(buySignal)
+	r.recordEntry(buySignal.Symbol, entryOrder)
+
+	return err
+}
+
+// ConsumeSellSignal passes the signal to the manager, if it would open a short position only when no limit
+// is broken.
+func (r *RiskManager) ConsumeSellSignal(sellSignal api.BuySignal) error {
+	ctx := context.Background()
+
+	opensShort, err := r.opensShortPosition(ctx, sellSignal)
+	if err != nil {
+		return err
+	}
+
+	if opensShort {
+		reason, err := r.checkEntry(ctx, sellSignal)
+		if err != nil {
+			return err
+		}
+
+		if reason != "" {
+			r.logger.Warn("Block sell signal", zap.String("symbol", sellSignal.Symbol), zap.String("reason", reason))
+
+			return nil
+		}
+	}
+
+	entryOrder, err := r.manager.consumeSellSignal(sellSignal)
+	r.recordEntry(sellSignal.Symbol, entryOrder)
+
+	return err
+}
+
+// recordEntry starts the cooldown of the coin if an entry order has been filled.
+func (r *RiskManager) recordEntry(coin string, entryOrder *Order) {
+	if entryOrder == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastEntries[strings.ToUpper(coin)] = r.now()
+}
+
+// Kill engages the kill switch, no new entry is made until Resume.
+func (r *RiskManager) Kill(reason string) {
+	r.kill(reason, time.Time{})
+}
+
+// kill engages the kill switch until the time, or until Resume if it is zero.
+func (r *RiskManager) kill(reason string, until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.killReason != "" {
+		return
+	}
+
+	r.killReason = reason
+	r.killUntil = until
+
+	if until.IsZero() {
+		r.logger.Error("Kill switch engaged", zap.String("reason", reason))
+	} else {
+		r.logger.Error("Kill switch engaged", zap.String("reason", reason), zap.Time("until", until))
+	}
+}
+
+// Resume disengages the kill switch engaged by Kill or the daily loss limit, the kill switch file has to be
+// removed separately.
+func (r *RiskManager) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resume()
+}
+
+// resume must be called with r.mu held.
+func (r *RiskManager) resume() {
+	if r.killReason != "" {
+		r.logger.Info("Kill switch disengaged", zap.String("reason", r.killReason))
+	}
+
+	r.killReason = ""
+	r.killUntil = time.Time{}
+}
+
+// KillReason returns why the kill switch is engaged, empty if it is not. The kill switch file engages it as
+// long as it exists, with its content as the reason. The one engaged by the daily loss limit disengages at
+// the start of the next UTC day.
+func (r *RiskManager) KillReason() string {
+	r.mu.Lock()
+	if !r.killUntil.IsZero() && !r.now().Before(r.killUntil) {
+		r.resume()
+	}
+
+	reason := r.killReason
+	r.mu.Unlock()
+
+	if reason != "" || r.riskOpts.killSwitchFile == "" {
+		return reason
+	}
+
+	b, err := os.ReadFile(r.riskOpts.killSwitchFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			r.logger.Error("Fail to read kill switch file", zap.Error(err))
+		}
+
+		return ""
+	}
+
+	if reason = strings.TrimSpace(string(b)); reason == "" {
+		reason = "kill switch file " + r.riskOpts.killSwitchFile + " exists"
+	}
+
+	return reason
+}
+
+// opensShortPosition returns whether the sell signal would open a short position rather than close a long one.
+func (r *RiskManager) opensShortPosition(ctx context.Context, sellSignal api.BuySignal) (bool, error) {
+	if !r.manager.futuresOpts.shortSelling {
+		return false, nil
+	}
+
+	resolved, err := r.manager.ResolveSymbol(sellSignal.Symbol)
+	if err != nil {
+		return false, err
+	}
+
+	positions, err := r.manager.getPositionAmounts(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return positions[resolved.Symbol.Symbol].IsZero(), nil
+}
+
+// checkEntry returns why a new entry of the signal breaks a limit, empty if it does not.
+func (r *RiskManager) checkEntry(ctx context.Context, buySignal api.BuySignal) (string, error) {
+	if reason := r.KillReason(); reason != "" {
+		return "kill switch: " + reason, nil
+	}
+
+	now := r.now()
+	coin := strings.ToUpper(buySignal.Symbol)
+
+	if limit := decimal.NewFromFloat(r.riskOpts.dailyLossLimitInUSD); limit.IsPositive() {
+		pnl, err := r.manager.exchange.GetRealizedPnL(ctx, startOfDay(now))
+		if err != nil {
+			return "", fmt.Errorf("get realized pnl: %w", err)
+		}
+
+		if loss := pnl.Neg(); loss.GreaterThanOrEqual(limit) {
+			r.kill(
+				fmt.Sprintf("daily realized loss %s reached the limit %s", loss.String(), limit.String()),
+				startOfDay(now).Add(24*time.Hour), // nolint: gomnd
+			)
+
+			return "kill switch: " + r.KillReason(), nil
+		}
+	}
+
+	if r.riskOpts.maxConcurrentPositions <= 0 && r.riskOpts.maxTotalNotionalInUSD <= 0 && r.riskOpts.entryCooldown <= 0 {
+		return "", nil
+	}
+
+	resolved, err := r.manager.ResolveSymbol(coin)
+	if err != nil {
+		return "", err
+	}
+
+	positions, err := r.manager.exchange.GetPositions(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get positions: %w", err)
+	}
+
+	hasPosition := false
+	totalNotional := decimal.Zero
+
+	for _, p := range positions {
+		if p.Symbol == resolved.Symbol.Symbol {
+			hasPosition = true
+		}
+
+		price := p.MarkPrice
+		if price.IsZero() {
+			price = p.EntryPrice
+		}
+
+		totalNotional = totalNotional.Add(p.Amount.Abs().Mul(price))
+	}
+
+	// adding to an open position is not a new entry, the add on stages decide whether it is made
+	if r.riskOpts.entryCooldown > 0 && !hasPosition {
+		r.mu.Lock()
+		lastEntry, ok := r.lastEntries[coin]
+		r.mu.Unlock()
+
+		if ok && now.Sub(lastEntry) < r.riskOpts.entryCooldown {
+			return fmt.Sprintf("cooldown until %s", lastEntry.Add(r.riskOpts.entryCooldown).Format(time.RFC3339)), nil
+		}
+	}
+
+	if maxPositions := r.riskOpts.maxConcurrentPositions; maxPositions > 0 && !hasPosition && len(positions) >= maxPositions {
+		return fmt.Sprintf("%d positions open, the max is %d", len(positions), maxPositions), nil
+	}
+
+	if maxNotional := decimal.NewFromFloat(r.riskOpts.maxTotalNotionalInUSD); maxNotional.IsPositive() {
+		entryNotional, err := r.manager.entryNotional(ctx, resolved.Symbol)
+		if err != nil {
+			return "", err
+		}
+
+		if total := totalNotional.Add(entryNotional); total.GreaterThan(maxNotional) {
+			return fmt.Sprintf("total notional %s would exceed the max %s", total.StringFixed(2), maxNotional.String()), nil // nolint: gomnd
+		}
+	}
+
+	return "", nil
+}
+
+// startOfDay returns the start of the UTC day of the time, when the daily loss limit is reset.
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour) // nolint: gomnd
+}
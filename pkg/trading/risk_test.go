@@ -0,0 +1,231 @@
+package trading
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newRiskTestManager(t *testing.T, exchange Exchange, riskOpts []RiskOption, opts ...FuturesOption) *RiskManager {
+	t.Helper()
+
+	m, err := NewBinanceFuturesManager(
+		exchange,
+		zap.NewNop(),
+		append([]FuturesOption{WithWillExecuteOrder(true), WithEachTradeAmountInUSD(100)}, opts...)...,
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.UpdateSupportedSymbols())
+
+	return NewRiskManager(m, zap.NewNop(), riskOpts...)
+}
+
+func newRiskTestPaperExchange() *PaperExchange {
+	return NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperInitialBalanceInUSD(1000),
+		WithPaperSlippagePercentage(0),
+	)
+}
+
+func getPositionSymbols(t *testing.T, exchange Exchange) []string {
+	t.Helper()
+
+	positions, err := exchange.GetPositions(context.Background())
+	require.NoError(t, err)
+
+	symbols := []string{}
+	for _, p := range positions {
+		symbols = append(symbols, p.Symbol)
+	}
+
+	return symbols
+}
+
+func TestRiskManagerMaxConcurrentPositions(t *testing.T) {
+	paper := newRiskTestPaperExchange()
+	r := newRiskTestManager(t, paper, []RiskOption{WithMaxConcurrentPositions(1)})
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	assert.Equal(t, []string{"SOLUSDT"}, getPositionSymbols(t, paper))
+
+	reason, err := r.checkEntry(context.Background(), api.BuySignal{Symbol: "SOL"})
+	require.NoError(t, err)
+	assert.Empty(t, reason, "adding to an open position does not count as a new one")
+}
+
+func TestRiskManagerMaxTotalNotional(t *testing.T) {
+	paper := newRiskTestPaperExchange()
+	r := newRiskTestManager(t, paper, []RiskOption{WithMaxTotalNotionalInUSD(150)})
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	assert.Equal(t, []string{"SOLUSDT"}, getPositionSymbols(t, paper))
+}
+
+func TestRiskManagerEntryCooldown(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	paper := newRiskTestPaperExchange()
+	r := newRiskTestManager(
+		t,
+		paper,
+		[]RiskOption{WithEntryCooldown(time.Hour)},
+		WithEntryStages(api.SignalStageTransfers),
+		WithAddOnStages(api.SignalStageTradingLive),
+	)
+	r.now = func() time.Time { return now }
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH", Stage: api.SignalStageTradingLive}))
+	assert.Empty(t, getPositionSymbols(t, paper))
+	assert.Empty(t, r.lastEntries, "a skipped signal is not an entry")
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTransfers}))
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTradingLive}))
+
+	positions, err := paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "5", positions[0].Amount.String(), "adding to the position is not subject to the cooldown")
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL", Side: api.SignalSideSell}))
+	assert.Empty(t, getPositionSymbols(t, paper))
+
+	reason, err := r.checkEntry(context.Background(), api.BuySignal{Symbol: "sol"})
+	require.NoError(t, err)
+	assert.Contains(t, reason, "cooldown")
+
+	reason, err = r.checkEntry(context.Background(), api.BuySignal{Symbol: "ETH"})
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	now = now.Add(time.Hour)
+
+	reason, err = r.checkEntry(context.Background(), api.BuySignal{Symbol: "SOL"})
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+}
+
+func TestRiskManagerDailyLossLimit(t *testing.T) {
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+	exchange.pnl = decimal.NewFromInt(-30)
+	r := newRiskTestManager(t, exchange, []RiskOption{WithDailyLossLimitInUSD(50)})
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+	require.NotEmpty(t, exchange.orders)
+	assert.Empty(t, r.KillReason())
+
+	orderCount := len(exchange.orders)
+
+	exchange.pnl = decimal.NewFromInt(-50)
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	require.Len(t, exchange.orders, orderCount)
+	assert.Contains(t, r.KillReason(), "daily realized loss 50")
+
+	exchange.pnl = decimal.Zero
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	require.Len(t, exchange.orders, orderCount, "the kill switch stays engaged until resumed")
+
+	r.Resume()
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	assert.Greater(t, len(exchange.orders), orderCount)
+}
+
+func TestRiskManagerDailyLossLimitReset(t *testing.T) {
+	now := time.Date(2021, 6, 1, 23, 0, 0, 0, time.UTC)
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+	exchange.pnl = decimal.NewFromInt(-50)
+	r := newRiskTestManager(t, exchange, []RiskOption{WithDailyLossLimitInUSD(50)})
+	r.now = func() time.Time { return now }
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+	assert.Empty(t, exchange.orders)
+	assert.NotEmpty(t, r.KillReason())
+
+	exchange.pnl = decimal.Zero
+	now = now.Add(59 * time.Minute)
+	assert.NotEmpty(t, r.KillReason())
+
+	now = now.Add(time.Minute)
+	assert.Empty(t, r.KillReason(), "the daily loss limit is reset at the start of the next UTC day")
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+	assert.NotEmpty(t, exchange.orders)
+
+	r.Kill("manual")
+	now = now.Add(48 * time.Hour)
+	assert.Equal(t, "manual", r.KillReason(), "a manual kill stays engaged until resumed")
+}
+
+func TestRiskManagerKillSwitch(t *testing.T) {
+	killSwitchFile := filepath.Join(t.TempDir(), "kill")
+	paper := newRiskTestPaperExchange()
+	r := newRiskTestManager(t, paper, []RiskOption{WithKillSwitchFile(killSwitchFile)}, WithShortSelling(true))
+	sellSignal := api.BuySignal{Symbol: "SOL", Side: api.SignalSideSell}
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+
+	require.NoError(t, os.WriteFile(killSwitchFile, []byte("maintenance\n"), 0o600))
+	assert.Equal(t, "maintenance", r.KillReason())
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	assert.Equal(t, []string{"SOLUSDT"}, getPositionSymbols(t, paper))
+
+	require.NoError(t, r.ConsumeSignal(sellSignal))
+	assert.Empty(t, getPositionSymbols(t, paper), "closing a long position is not blocked")
+
+	require.NoError(t, r.ConsumeSignal(sellSignal))
+	assert.Empty(t, getPositionSymbols(t, paper), "opening a short position is blocked")
+
+	require.NoError(t, os.Remove(killSwitchFile))
+	assert.Empty(t, r.KillReason())
+
+	r.Kill("manual")
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	assert.Empty(t, getPositionSymbols(t, paper))
+
+	r.Resume()
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH"}))
+	assert.Equal(t, []string{"ETHUSDT"}, getPositionSymbols(t, paper))
+}
+
+func TestRiskManagerShortEntry(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	paper := newRiskTestPaperExchange()
+	r := newRiskTestManager(
+		t,
+		paper,
+		[]RiskOption{WithMaxConcurrentPositions(1), WithEntryCooldown(time.Hour)},
+		WithShortSelling(true),
+	)
+	r.now = func() time.Time { return now }
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH", Side: api.SignalSideSell}))
+	assert.Equal(t, []string{"SOLUSDT"}, getPositionSymbols(t, paper), "opening a short position is subject to the limits")
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL", Side: api.SignalSideSell}))
+	assert.Empty(t, getPositionSymbols(t, paper), "closing a long position is not blocked")
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "SOL", Side: api.SignalSideSell}))
+	assert.Empty(t, getPositionSymbols(t, paper), "the short position is within the cooldown of the long one")
+
+	require.NoError(t, r.ConsumeSignal(api.BuySignal{Symbol: "ETH", Side: api.SignalSideSell}))
+
+	positions, err := paper.GetPositions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "ETHUSDT", positions[0].Symbol)
+	assert.True(t, positions[0].Amount.IsNegative())
+	assert.Equal(t, now, r.lastEntries["ETH"], "the short entry starts the cooldown")
+}
@@ -0,0 +1,157 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const minNotionalFilterType = "MIN_NOTIONAL"
+
+var (
+	errUnknownSizingMode         = errors.New("unknown sizing mode")
+	errFixedRiskWithoutStopLoss  = errors.New("fixed risk sizing requires a stop loss")
+	errInvalidBalancePercentage  = errors.New("balance percentage sizing requires a percentage in (0, 100]")
+	errQuantityBelowMinimum      = errors.New("quantity below the minimum of the symbol")
+	errNotionalBelowMinimum      = errors.New("notional below the minimum of the symbol")
+	errNonPositiveAvailableFunds = errors.New("no available balance")
+)
+
+// SizingMode decides the notional amount of a new position.
+type SizingMode string
+
+const (
+	// SizingModeFixed trades the same notional amount every time.
+	SizingModeFixed SizingMode = "fixed"
+	// SizingModeBalancePercentage puts a percentage of the available balance up as the margin, the notional
+	// amount is the margin times the leverage.
+	SizingModeBalancePercentage SizingMode = "balance_percentage"
+	// SizingModeFixedRisk loses the same amount every time the stop loss is hit, the notional amount is the
+	// risk divided by the stop loss distance.
+	SizingModeFixedRisk SizingMode = "fixed_risk"
+)
+
+func ParseSizingMode(s string) (SizingMode, error) {
+	switch mode := SizingMode(s); mode {
+	case SizingModeFixed, SizingModeBalancePercentage, SizingModeFixedRisk:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %s", errUnknownSizingMode, s)
+	}
+}
+
+// entryQuantity returns the quantity of a new position at the price by the sizing mode, capped by the max
+// notional amount and clamped to the lot size and notional filters of the symbol.
+func (m *BinanceFuturesManager) entryQuantity(ctx context.Context, futuresSymbol futures.Symbol, price decimal.Decimal) (decimal.Decimal, error) {
+	notional, err := m.entryNotional(ctx, futuresSymbol)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	if maxNotional := decimal.NewFromFloat(m.futuresOpts.maxNotionalInUSD); maxNotional.IsPositive() && notional.GreaterThan(maxNotional) {
+		m.logger.Info(
+			"Cap notional amount",
+			zap.String("symbol", futuresSymbol.Symbol),
+			zap.String("notional", notional.String()),
+			zap.String("maxNotional", maxNotional.String()),
+		)
+
+		notional = maxNotional
+	}
+
+	return clampQuantity(futuresSymbol, notional.Div(price), price)
+}
+
+func (m *BinanceFuturesManager) entryNotional(ctx context.Context, futuresSymbol futures.Symbol) (decimal.Decimal, error) {
+	switch m.futuresOpts.sizingMode {
+	case SizingModeBalancePercentage:
+		balance, err := m.exchange.GetAvailableBalance(ctx, futuresSymbol.QuoteAsset)
+		if err != nil {
+			return decimal.Decimal{}, fmt.Errorf("get available balance: %w", err)
+		}
+
+		if !balance.IsPositive() {
+			return decimal.Decimal{}, fmt.Errorf("%w: %s %s", errNonPositiveAvailableFunds, balance.String(), futuresSymbol.QuoteAsset)
+		}
+
+		return balance.
+			Mul(decimal.NewFromFloat(m.futuresOpts.balancePercentage)).
+			Div(decimal.NewFromInt(100)). // nolint: gomnd
+			Mul(decimal.NewFromInt(int64(m.futuresOpts.leverage))), nil
+	case SizingModeFixedRisk:
+		return decimal.NewFromFloat(m.futuresOpts.riskPerTradeInUSD).
+			Mul(decimal.NewFromInt(100)). // nolint: gomnd
+			Div(decimal.NewFromFloat(m.futuresOpts.stopLossPriceChangedPercentage)), nil
+	case SizingModeFixed:
+		return decimal.NewFromFloat(m.futuresOpts.eachTradeAmountInUSD), nil
+	default:
+		return decimal.Decimal{}, fmt.Errorf("%w: %s", errUnknownSizingMode, m.futuresOpts.sizingMode)
+	}
+}
+
+// clampQuantity caps the quantity by the max quantity of the MARKET_LOT_SIZE filter and rounds it down to its
+// step size, or to the quantity precision of symbols without the filter. A quantity below the min quantity
+// or the MIN_NOTIONAL filter is an error rather than being raised, the position would be larger than sized.
+func clampQuantity(futuresSymbol futures.Symbol, qty decimal.Decimal, price decimal.Decimal) (decimal.Decimal, error) {
+	minQty := decimal.Zero
+
+	if f := futuresSymbol.MarketLotSizeFilter(); f != nil {
+		maxQty, err := parseDecimal(f.MaxQuantity)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+
+		if minQty, err = parseDecimal(f.MinQuantity); err != nil {
+			return decimal.Decimal{}, err
+		}
+
+		stepSize, err := parseDecimal(f.StepSize)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+
+		if maxQty.IsPositive() && qty.GreaterThan(maxQty) {
+			qty = maxQty
+		}
+
+		if stepSize.IsPositive() {
+			qty = qty.Div(stepSize).Floor().Mul(stepSize)
+		}
+	} else {
+		qty = qty.Round(int32(futuresSymbol.QuantityPrecision))
+	}
+
+	if !qty.IsPositive() || qty.LessThan(minQty) {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s < %s", errQuantityBelowMinimum, qty.String(), minQty.String())
+	}
+
+	minNotional, err := getMinNotional(futuresSymbol)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	if notional := qty.Mul(price); notional.LessThan(minNotional) {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s < %s", errNotionalBelowMinimum, notional.String(), minNotional.String())
+	}
+
+	return qty, nil
+}
+
+// getMinNotional returns the notional of the MIN_NOTIONAL filter, zero if the symbol does not have one.
+func getMinNotional(futuresSymbol futures.Symbol) (decimal.Decimal, error) {
+	for _, f := range futuresSymbol.Filters {
+		if f["filterType"] != minNotionalFilterType {
+			continue
+		}
+
+		if s, ok := f["notional"].(string); ok {
+			return parseDecimal(s)
+		}
+	}
+
+	return decimal.Zero, nil
+}
@@ -0,0 +1,116 @@
+package trading
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newSizingTestSymbol() futures.Symbol {
+	return futures.Symbol{
+		Symbol:            "SOLUSDT",
+		Status:            "TRADING",
+		BaseAsset:         "SOL",
+		QuoteAsset:        "USDT",
+		QuantityPrecision: 2,
+		Filters: []map[string]interface{}{
+			{
+				"filterType": string(futures.SymbolFilterTypeMarketLotSize),
+				"minQty":     "1",
+				"maxQty":     "100",
+				"stepSize":   "1",
+			},
+			{
+				"filterType": minNotionalFilterType,
+				"notional":   "50",
+			},
+		},
+	}
+}
+
+func TestEntryQuantity(t *testing.T) {
+	testCases := []struct {
+		opts    []FuturesOption
+		balance float64
+		price   float64
+		qty     string
+		err     error
+	}{
+		// 500 / 40 = 12.5, rounded down to the step size
+		{opts: nil, price: 40, qty: "12"},
+		// 1000 * 10% * 5x = 500
+		{opts: []FuturesOption{WithSizingMode(SizingModeBalancePercentage), WithBalancePercentage(10)}, balance: 1000, price: 40, qty: "12"},
+		{opts: []FuturesOption{WithSizingMode(SizingModeBalancePercentage), WithBalancePercentage(10)}, balance: 0, price: 40, err: errNonPositiveAvailableFunds},
+		// 20 / 2% = 1000
+		{opts: []FuturesOption{WithSizingMode(SizingModeFixedRisk), WithRiskPerTradeInUSD(20), WithStopLossPriceChangedPercentage(2)}, price: 40, qty: "25"},
+		{opts: []FuturesOption{WithSizingMode(SizingModeFixedRisk), WithRiskPerTradeInUSD(20), WithStopLossPriceChangedPercentage(2), WithMaxNotionalInUSD(400)}, price: 40, qty: "10"},
+		// capped by the max quantity of the symbol
+		{opts: []FuturesOption{WithEachTradeAmountInUSD(10000)}, price: 40, qty: "100"},
+		{opts: []FuturesOption{WithEachTradeAmountInUSD(30)}, price: 40, err: errQuantityBelowMinimum},
+		// 1 * 40 < 50
+		{opts: []FuturesOption{WithEachTradeAmountInUSD(60)}, price: 40, err: errNotionalBelowMinimum},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			exchange := newFakeExchange(decimal.NewFromFloat(tc.price))
+			exchange.balance = decimal.NewFromFloat(tc.balance)
+
+			m, err := NewBinanceFuturesManager(exchange, zap.NewNop(), tc.opts...)
+			require.NoError(t, err)
+
+			qty, err := m.entryQuantity(context.Background(), newSizingTestSymbol(), decimal.NewFromFloat(tc.price))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.qty, qty.String())
+		})
+	}
+}
+
+func TestFixedRiskSizingWithoutStopLoss(t *testing.T) {
+	_, err := NewBinanceFuturesManager(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithSizingMode(SizingModeFixedRisk),
+		WithRiskPerTradeInUSD(20),
+	)
+	assert.ErrorIs(t, err, errFixedRiskWithoutStopLoss)
+}
+
+func TestBalancePercentageSizingOutOfRange(t *testing.T) {
+	for i, percentage := range []float64{0, -5, 150} {
+		percentage := percentage
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			_, err := NewBinanceFuturesManager(
+				newFakeExchange(decimal.NewFromInt(40)),
+				zap.NewNop(),
+				WithSizingMode(SizingModeBalancePercentage),
+				WithBalancePercentage(percentage),
+			)
+			assert.ErrorIs(t, err, errInvalidBalancePercentage)
+		})
+	}
+}
+
+func TestParseSizingMode(t *testing.T) {
+	mode, err := ParseSizingMode("balance_percentage")
+	require.NoError(t, err)
+	assert.Equal(t, SizingModeBalancePercentage, mode)
+
+	_, err = ParseSizingMode("kelly")
+	assert.ErrorIs(t, err, errUnknownSizingMode)
+}
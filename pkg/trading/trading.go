@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/journal"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
@@ -15,124 +18,284 @@ import (
 var (
 	errEmptyPriceList = errors.New("empty price list")
 	errSymbolNotFound = errors.New("futures symbol not found")
+	errOrderNotFound  = errors.New("order not found")
 )
 
 type BinanceFuturesManager struct {
-	futuresClient *futures.Client
-	futuresOpts   futuresOptions
-	logger        *zap.Logger
+	exchange    Exchange
+	futuresOpts futuresOptions
+	logger      *zap.Logger
 
 	mu               sync.Mutex
 	supportedSymbols map[string]futures.Symbol
+	exitOrders       map[string][]int64
+	deadlines        map[string]time.Time
+
+	now func() time.Time
 }
 
-func NewBinanceFuturesManager(futuresClient *futures.Client, logger *zap.Logger, opts ...FuturesOption) (*BinanceFuturesManager, error) {
+func NewBinanceFuturesManager(exchange Exchange, logger *zap.Logger, opts ...FuturesOption) (*BinanceFuturesManager, error) {
 	options := newDefaultFuturesOptions()
 	for _, o := range opts {
 		o.apply(&options)
 	}
 
-	supportedSymbols, err := getSymbolsInfo(futuresClient)
+	if options.sizingMode == SizingModeFixedRisk && options.stopLossPriceChangedPercentage <= 0 {
+		return nil, errFixedRiskWithoutStopLoss
+	}
+
+	if rate := options.trailingStopCallbackRate; options.exitMode == ExitModeTrailingStop &&
+		(rate < minTrailingStopCallbackRate || rate > maxTrailingStopCallbackRate) {
+		return nil, fmt.Errorf("%w: %v", errInvalidTrailingStopCallbackRate, rate)
+	}
+
+	if options.sizingMode == SizingModeBalancePercentage && (options.balancePercentage <= 0 || options.balancePercentage > 100) {
+		return nil, fmt.Errorf("%w: %v", errInvalidBalancePercentage, options.balancePercentage)
+	}
+
+	if options.entryMode == EntryModeLimitGTX && options.entryMaxSlippagePercentage > 0 {
+		return nil, errPostOnlyEntryAboveMarket
+	}
+
+	supportedSymbols, err := exchange.GetSymbolsInfo(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
+	deadlines := make(map[string]time.Time)
+	if options.deadlineStore != nil {
+		deadlines, err = options.deadlineStore.LoadDeadlines()
+		if err != nil {
+			return nil, fmt.Errorf("load position deadlines: %w", err)
+		}
+	}
+
 	return &BinanceFuturesManager{
-		futuresClient:    futuresClient,
+		exchange:         exchange,
 		futuresOpts:      options,
 		logger:           logger,
 		supportedSymbols: supportedSymbols,
+		exitOrders:       make(map[string][]int64),
+		deadlines:        deadlines,
+		now:              time.Now,
 	}, nil
 }
 
-func (m *BinanceFuturesManager) ConsumeBuySignal(buySignal api.BuySignal) error {
-	symbol := buySignal.Symbol + "USDT"
+// ConsumeSignal consumes the signal as a sell signal if its side is sell, otherwise as a buy signal.
+func (m *BinanceFuturesManager) ConsumeSignal(signal api.BuySignal) error {
+	if signal.IsSell() {
+		return m.ConsumeSellSignal(signal)
+	}
 
-	return m.createLongPosition(symbol)
+	return m.ConsumeBuySignal(signal)
 }
 
-func (m *BinanceFuturesManager) createLongPosition(symbol string) error {
+// ConsumeSellSignal closes the long position of the symbol if there is one, otherwise it opens a short
+// position if short selling is enabled.
+func (m *BinanceFuturesManager) ConsumeSellSignal(sellSignal api.BuySignal) error {
+	_, err := m.consumeSellSignal(sellSignal)
+
+	return err
+}
+
+// consumeSellSignal returns the entry order of the short position opened by the signal, nil if the signal
+// closes a long position, is skipped or no order is executed.
+func (m *BinanceFuturesManager) consumeSellSignal(sellSignal api.BuySignal) (*Order, error) {
 	ctx := context.Background()
 
-	futuresSymbol, err := m.getSymbol(symbol)
+	resolved, err := m.ResolveSymbol(sellSignal.Symbol)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	price, err := getPrice(m.futuresClient, symbol)
+	symbol := resolved.Symbol.Symbol
+
+	positions, err := m.getPositionAmounts(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	amt := positions[symbol]
+
+	switch {
+	case amt.IsPositive():
+		m.logger.Info("Closing long position on sell signal", zap.String("symbol", symbol), zap.String("source", sellSignal.Source))
+
+		if !m.futuresOpts.willExecuteOrder {
+			return nil, nil
+		}
+
+		return nil, m.closePosition(ctx, symbol)
+	case amt.IsNegative():
+		m.logger.Info("Skip sell signal of an open short position", zap.String("symbol", symbol))
+
+		return nil, nil
+	case !m.futuresOpts.shortSelling:
+		m.logger.Info("Skip sell signal as short selling is disabled", zap.String("symbol", symbol))
+
+		return nil, nil
+	default:
+		return m.openPosition(symbol, futures.SideTypeSell)
 	}
+}
 
-	qtyPrecision := futuresSymbol.QuantityPrecision
-	qty := decimal.NewFromInt(1).
-		Div(price).
-		Mul(decimal.NewFromFloat(m.futuresOpts.eachTradeAmountInUSD)).
-		Round(int32(qtyPrecision))
+// ConsumeBuySignal opens a long position if the stage of the signal is an entry stage, or adds to the open
+// position of the symbol if it is an add on stage.
+func (m *BinanceFuturesManager) ConsumeBuySignal(buySignal api.BuySignal) error {
+	_, err := m.consumeBuySignal(buySignal)
+
+	return err
+}
 
-	_, err = m.futuresClient.NewChangeLeverageService().
-		Symbol(symbol).
-		Leverage(m.futuresOpts.leverage).
-		Do(ctx)
+// consumeBuySignal returns the entry order of the long position opened by the signal, nil if the signal is
+// skipped, adds to an open position or no order is executed.
+func (m *BinanceFuturesManager) consumeBuySignal(buySignal api.BuySignal) (*Order, error) {
+	resolved, err := m.ResolveSymbol(buySignal.Symbol)
 	if err != nil {
-		return fmt.Errorf("change leverage: %w", err)
+		return nil, err
 	}
 
-	createOrderService := m.futuresClient.NewCreateOrderService()
+	symbol := resolved.Symbol.Symbol
+	if !resolved.Multiplier.Equal(decimal.NewFromInt(1)) {
+		m.logger.Info(
+			"Resolved multiplier contract",
+			zap.String("coin", buySignal.Symbol),
+			zap.String("symbol", symbol),
+			zap.String("multiplier", resolved.Multiplier.String()),
+		)
+	}
 
-	if !m.futuresOpts.willExecuteOrder {
-		m.logger.Sugar().Infof("Trying to buy %s at ~%s with %s amount", symbol, price.String(), qty.String())
+	positions, err := m.getPositionAmounts(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if amt := positions[symbol]; !amt.IsZero() {
+		if !containsStage(m.futuresOpts.addOnStages, buySignal.Stage) {
+			m.logger.Info("Skip buy signal of an open position", zap.String("symbol", symbol), zap.String("stage", string(buySignal.Stage)))
+
+			return nil, nil
+		}
+
+		return nil, m.addToLongPosition(symbol)
+	}
+
+	if buySignal.Stage != api.SignalStageUnspecified && !containsStage(m.futuresOpts.entryStages, buySignal.Stage) {
+		m.logger.Info("Skip buy signal of a non entry stage", zap.String("symbol", symbol), zap.String("stage", string(buySignal.Stage)))
+
+		return nil, nil
+	}
+
+	return m.openPosition(symbol, futures.SideTypeBuy)
+}
+
+func (m *BinanceFuturesManager) createLongPosition(symbol string) error {
+	return m.createPosition(symbol, futures.SideTypeBuy)
+}
+
+// createShortPosition mirrors createLongPosition, the take profit is below the entry price and the
+// stop loss above it.
+func (m *BinanceFuturesManager) createShortPosition(symbol string) error {
+	return m.createPosition(symbol, futures.SideTypeSell)
+}
 
-		return nil
+func (m *BinanceFuturesManager) createPosition(symbol string, side futures.SideType) error {
+	_, err := m.openPosition(symbol, side)
+
+	return err
+}
+
+// openPosition returns the entry order of the position opened, nil if no order is executed or nothing is filled.
+func (m *BinanceFuturesManager) openPosition(symbol string, side futures.SideType) (*Order, error) {
+	ctx := context.Background()
+
+	futuresSymbol, entryOrder, err := m.openOrder(ctx, symbol, side)
+	if err != nil || entryOrder == nil {
+		return nil, err
+	}
+
+	if err := m.scheduleExit(symbol); err != nil {
+		m.logger.Error("Fail to schedule position exit", zap.String("symbol", symbol), zap.Error(err))
 	}
 
-	createOrderResp, err := createOrderService.
-		Symbol(symbol).
-		Side(futures.SideTypeBuy).
-		Type(futures.OrderTypeMarket).
-		Quantity(qty.String()).
-		Do(ctx)
+	return entryOrder, m.placeExitOrders(ctx, futuresSymbol, entryOrder)
+}
+
+// addToLongPosition buys another trade amount of the symbol, and replaces the exit orders with ones
+// for the whole position at its new entry price.
+func (m *BinanceFuturesManager) addToLongPosition(symbol string) error {
+	ctx := context.Background()
+
+	_, entryOrder, err := m.openOrder(ctx, symbol, futures.SideTypeBuy)
+	if err != nil || entryOrder == nil {
+		return err
+	}
+
+	openOrders, err := m.exchange.ListOpenOrders(ctx, symbol)
 	if err != nil {
-		return fmt.Errorf("create buy order: %w", err)
+		return fmt.Errorf("list open orders: %w", err)
+	}
+
+	for _, o := range openOrders {
+		if !isExitOrder(o) {
+			continue
+		}
+
+		if err := m.cancelOrder(ctx, journal.OrderKindExit, symbol, o.OrderID); err != nil {
+			return fmt.Errorf("cancel exit order: %w", err)
+		}
 	}
-	m.logger.Sugar().Infof("Executed a %s buy order at ~%s with %s amount", symbol, price.String(), qty.String())
 
-	getOrderResp, err := m.futuresClient.NewGetOrderService().
-		OrderID(createOrderResp.OrderID).
-		Symbol(symbol).
-		Do(ctx)
+	m.untrackExitOrders(symbol)
+
+	positions, err := m.exchange.GetPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("get positions: %w", err)
+	}
+
+	for _, p := range positions {
+		if p.Symbol == symbol {
+			return m.protectPosition(ctx, p)
+		}
+	}
+
+	return nil
+}
+
+// openOrder sends an entry order of the size by the sizing mode, the returned order is nil if orders are not executed
+// or nothing is filled.
+func (m *BinanceFuturesManager) openOrder(ctx context.Context, symbol string, side futures.SideType) (futures.Symbol, *Order, error) {
+	futuresSymbol, err := m.getSymbol(symbol)
 	if err != nil {
-		return fmt.Errorf("get order: %w", err)
+		return futures.Symbol{}, nil, err
 	}
 
-	avgPrice, err := decimal.NewFromString(getOrderResp.AvgPrice)
+	price, err := m.exchange.GetPrice(ctx, symbol)
 	if err != nil {
-		return fmt.Errorf("convert average price string to decimal: %w", err)
+		return futures.Symbol{}, nil, err
 	}
 
-	tickSize, err := decimal.NewFromString(futuresSymbol.PriceFilter().TickSize)
+	qty, err := m.entryQuantity(ctx, futuresSymbol, price)
 	if err != nil {
-		return fmt.Errorf("convert tick size string to decimal: %w", err)
-	}
-
-	multiplier := decimal.NewFromFloat(m.futuresOpts.takeProfitPriceChangedPercentage).
-		Div(decimal.NewFromInt(100)). // nolint: gomnd
-		Add(decimal.NewFromInt(1))
-	stopPrice := roundToTickSize(avgPrice.Mul(multiplier), tickSize)
-
-	_, err = createOrderService.
-		Symbol(symbol).
-		Side(futures.SideTypeSell).
-		Type(futures.OrderTypeTakeProfitMarket).
-		TimeInForce(futures.TimeInForceTypeGTC).
-		ClosePosition(true).
-		StopPrice(stopPrice.String()).
-		Do(ctx)
+		return futures.Symbol{}, nil, err
+	}
+
+	if err := m.exchange.ChangeLeverage(ctx, symbol, m.futuresOpts.leverage); err != nil {
+		return futures.Symbol{}, nil, err
+	}
+
+	if !m.futuresOpts.willExecuteOrder {
+		m.logger.Sugar().Infof("Trying to %s %s at ~%s with %s amount", strings.ToLower(string(side)), symbol, price.String(), qty.String())
+
+		return futuresSymbol, nil, nil
+	}
+
+	entryOrder, err := m.placeEntryOrder(ctx, futuresSymbol, side, price, qty)
 	if err != nil {
-		return fmt.Errorf("create take profit order: %w", err)
+		return futures.Symbol{}, nil, err
 	}
 
-	return nil
+	return futuresSymbol, entryOrder, nil
 }
 
 func (m *BinanceFuturesManager) getSymbol(symbol string) (futures.Symbol, error) {
@@ -148,7 +311,7 @@ func (m *BinanceFuturesManager) getSymbol(symbol string) (futures.Symbol, error)
 }
 
 func (m *BinanceFuturesManager) UpdateSupportedSymbols() error {
-	symbols, err := getSymbolsInfo(m.futuresClient)
+	symbols, err := m.exchange.GetSymbolsInfo(context.Background())
 	if err != nil {
 		return err
 	}
@@ -161,42 +324,16 @@ func (m *BinanceFuturesManager) UpdateSupportedSymbols() error {
 	return nil
 }
 
-func getSymbolsInfo(futuresClient *futures.Client) (map[string]futures.Symbol, error) {
-	resp, err := futuresClient.
-		NewExchangeInfoService().
-		Do(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("get exchange info: %w", err)
-	}
-
-	res := make(map[string]futures.Symbol, len(resp.Symbols))
-	for _, s := range resp.Symbols {
-		res[s.Symbol] = s
-	}
-
-	return res, nil
+func roundToTickSize(price decimal.Decimal, tickSize decimal.Decimal) decimal.Decimal {
+	return price.DivRound(tickSize, 0).Mul(tickSize)
 }
 
-func getPrice(futuresClient *futures.Client, symbol string) (decimal.Decimal, error) {
-	res, err := futuresClient.NewListPricesService().
-		Symbol(symbol).
-		Do(context.Background())
-	if err != nil {
-		return decimal.Decimal{}, fmt.Errorf("binance futures list prices: %w", err)
+func containsStage(stages []api.SignalStage, stage api.SignalStage) bool {
+	for _, s := range stages {
+		if s == stage {
+			return true
+		}
 	}
 
-	if len(res) == 0 {
-		return decimal.Decimal{}, errEmptyPriceList
-	}
-
-	p, err := decimal.NewFromString(res[0].Price)
-	if err != nil {
-		return decimal.Decimal{}, fmt.Errorf("convert price string to decimal: %w", err)
-	}
-
-	return p, nil
-}
-
-func roundToTickSize(price decimal.Decimal, tickSize decimal.Decimal) decimal.Decimal {
-	return price.DivRound(tickSize, 0).Mul(tickSize)
+	return false
 }
@@ -1,13 +1,227 @@
 package trading
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/journal"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
+type fakeExchange struct {
+	symbols  map[string]futures.Symbol
+	price    decimal.Decimal
+	balance  decimal.Decimal
+	pnl      decimal.Decimal
+	leverage map[string]int
+	orders   []*Order
+	// requests of the orders, binance does not return the close position flag of a queried order
+	requests []OrderRequest
+	// quantities filled by the next limit orders, a limit order within its limit is fully filled once it runs out
+	limitFills []decimal.Decimal
+	// orders of the type are rejected
+	rejectedOrderType futures.OrderType
+	positions         []*Position
+}
+
+var errFakeOrderRejected = errors.New("fake order rejected")
+
+func newFakeExchange(price decimal.Decimal) *fakeExchange {
+	return &fakeExchange{
+		symbols: map[string]futures.Symbol{
+			"SOLUSDT": {
+				Symbol:            "SOLUSDT",
+				Status:            "TRADING",
+				BaseAsset:         "SOL",
+				QuoteAsset:        "USDT",
+				QuantityPrecision: 1,
+				Filters: []map[string]interface{}{
+					{
+						"filterType": string(futures.SymbolFilterTypePrice),
+						"tickSize":   "0.01",
+					},
+				},
+			},
+			"ETHUSDT": {
+				Symbol:            "ETHUSDT",
+				Status:            "TRADING",
+				BaseAsset:         "ETH",
+				QuoteAsset:        "USDT",
+				QuantityPrecision: 3,
+				Filters: []map[string]interface{}{
+					{
+						"filterType": string(futures.SymbolFilterTypePrice),
+						"tickSize":   "0.01",
+					},
+				},
+			},
+		},
+		price:    price,
+		leverage: map[string]int{},
+	}
+}
+
+func (e *fakeExchange) GetSymbolsInfo(ctx context.Context) (map[string]futures.Symbol, error) {
+	return e.symbols, nil
+}
+
+func (e *fakeExchange) GetPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	return e.price, nil
+}
+
+func (e *fakeExchange) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	e.leverage[symbol] = leverage
+
+	return nil
+}
+
+func (e *fakeExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	if req.Type == e.rejectedOrderType {
+		return nil, errFakeOrderRejected
+	}
+
+	o := &Order{
+		Symbol:       req.Symbol,
+		OrderID:      int64(len(e.orders) + 1),
+		Side:         req.Side,
+		Type:         req.Type,
+		Status:       futures.OrderStatusTypeNew,
+		Price:        req.Price,
+		StopPrice:    req.StopPrice,
+		OrigQuantity: req.Quantity,
+		ReduceOnly:   req.ReduceOnly,
+	}
+	if req.Type == futures.OrderTypeMarket {
+		o.Status = futures.OrderStatusTypeFilled
+		o.ExecutedQuantity = req.Quantity
+		o.AvgPrice = e.price
+	}
+
+	if req.Type == futures.OrderTypeLimit {
+		o.Status = futures.OrderStatusTypeExpired
+
+		fillQty := decimal.Zero
+		if isWithinLimit(o, e.price) {
+			fillQty = req.Quantity
+		}
+
+		if len(e.limitFills) > 0 {
+			fillQty = decimal.Min(fillQty, e.limitFills[0])
+			e.limitFills = e.limitFills[1:]
+		}
+
+		if fillQty.Equal(req.Quantity) {
+			o.Status = futures.OrderStatusTypeFilled
+		}
+
+		o.ExecutedQuantity = fillQty
+		o.AvgPrice = e.price
+	}
+
+	e.orders = append(e.orders, o)
+	e.requests = append(e.requests, req)
+
+	res := *o
+	res.ClosePosition = req.ClosePosition
+
+	return &res, nil
+}
+
+func (e *fakeExchange) CancelOrder(ctx context.Context, symbol string, orderID int64) error {
+	for _, o := range e.orders {
+		if o.OrderID == orderID {
+			o.Status = futures.OrderStatusTypeCanceled
+		}
+	}
+
+	return nil
+}
+
+func (e *fakeExchange) GetOrder(ctx context.Context, symbol string, orderID int64) (*Order, error) {
+	for _, o := range e.orders {
+		if o.OrderID == orderID {
+			return o, nil
+		}
+	}
+
+	return nil, errOrderNotFound
+}
+
+func (e *fakeExchange) ListOpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	res := []*Order{}
+
+	for _, o := range e.orders {
+		if o.Symbol == symbol && o.Status == futures.OrderStatusTypeNew {
+			res = append(res, o)
+		}
+	}
+
+	return res, nil
+}
+
+func (e *fakeExchange) GetPositions(ctx context.Context) ([]*Position, error) {
+	return append([]*Position{}, e.positions...), nil
+}
+
+func (e *fakeExchange) GetAvailableBalance(ctx context.Context, asset string) (decimal.Decimal, error) {
+	return e.balance, nil
+}
+
+func (e *fakeExchange) GetRealizedPnL(ctx context.Context, since time.Time) (decimal.Decimal, error) {
+	return e.pnl, nil
+}
+
+func TestCreateLongPosition(t *testing.T) {
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+
+	m, err := NewBinanceFuturesManager(
+		exchange,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithLeverage(3),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	assert.Equal(t, 3, exchange.leverage["SOLUSDT"])
+	require.Len(t, exchange.orders, 2)
+
+	buyOrder := exchange.orders[0]
+	assert.Equal(t, futures.SideTypeBuy, buyOrder.Side)
+	assert.Equal(t, futures.OrderTypeMarket, buyOrder.Type)
+	assert.Equal(t, "2.5", buyOrder.OrigQuantity.String())
+
+	takeProfitOrder := exchange.orders[1]
+	assert.Equal(t, futures.SideTypeSell, takeProfitOrder.Side)
+	assert.Equal(t, futures.OrderTypeTakeProfitMarket, takeProfitOrder.Type)
+	assert.True(t, exchange.requests[1].ClosePosition)
+	assert.Equal(t, "42", takeProfitOrder.StopPrice.String())
+}
+
+func TestCreateLongPositionWithoutExecution(t *testing.T) {
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+
+	m, err := NewBinanceFuturesManager(exchange, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+	assert.Empty(t, exchange.orders)
+
+	assert.ErrorIs(t, m.createLongPosition("DOGEUSDT"), errSymbolNotFound)
+}
+
 func TestRoundToTickSize(t *testing.T) {
 	testCases := []struct {
 		price    decimal.Decimal
@@ -32,3 +246,244 @@ func TestRoundToTickSize(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateLongPositionWithStopLoss(t *testing.T) {
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+		WithStopLossPriceChangedPercentage(2.5),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	openOrders, err := paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 2)
+
+	stopPrices := map[futures.OrderType]string{}
+	for _, o := range openOrders {
+		stopPrices[o.Type] = o.StopPrice.String()
+	}
+
+	assert.Equal(t, "42", stopPrices[futures.OrderTypeTakeProfitMarket])
+	assert.Equal(t, "39", stopPrices[futures.OrderTypeStopMarket])
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(39))
+	require.NoError(t, m.CheckExitOrders())
+
+	openOrders, err = paper.ListOpenOrders(context.Background(), "SOLUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders)
+	assert.Empty(t, m.getExitOrders())
+	assert.Equal(t, "-2.5", paper.PnL().RealizedPnL.String())
+}
+
+func TestCreateLongPositionWithJournal(t *testing.T) {
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.db"))
+	require.NoError(t, err)
+
+	defer func() {
+		_ = j.Close()
+	}()
+
+	paper := NewPaperExchange(
+		newFakeExchange(decimal.NewFromInt(40)),
+		zap.NewNop(),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithStopLossPriceChangedPercentage(2.5),
+		WithJournal(j),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.createLongPosition("SOLUSDT"))
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(42))
+	require.NoError(t, m.CheckExitOrders())
+
+	orders, err := j.ListOrders("SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+
+	statuses := map[journal.OrderKind][]string{}
+	for _, o := range orders {
+		statuses[o.Kind] = append(statuses[o.Kind], o.Type+" "+o.Status)
+	}
+
+	assert.Equal(t, []string{"MARKET FILLED"}, statuses[journal.OrderKindEntry])
+	assert.ElementsMatch(t, []string{"TAKE_PROFIT_MARKET FILLED", "STOP_MARKET CANCELED"}, statuses[journal.OrderKindExit])
+
+	fills, err := j.ListFills("SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, fills, 2)
+	assert.Equal(t, "40", fills[0].Price)
+	assert.Equal(t, "42", fills[1].Price)
+}
+
+func TestConsumeBuySignalStages(t *testing.T) {
+	marketData := newFakeExchange(decimal.NewFromInt(40))
+	paper := NewPaperExchange(
+		marketData,
+		zap.NewNop(),
+		WithPaperInitialBalanceInUSD(1000),
+		WithPaperSlippagePercentage(0),
+	)
+
+	m, err := NewBinanceFuturesManager(
+		paper,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+		WithStopLossPriceChangedPercentage(10),
+		WithEntryStages(api.SignalStageTransfers),
+		WithAddOnStages(api.SignalStageTradingLive),
+	)
+	require.NoError(t, err)
+	require.NoError(t, m.UpdateSupportedSymbols())
+
+	ctx := context.Background()
+
+	require.NoError(t, m.ConsumeBuySignal(api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTradingLive}))
+
+	positions, err := paper.GetPositions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, positions, "trading live is not an entry stage")
+
+	require.NoError(t, m.ConsumeBuySignal(api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTransfers}))
+	require.NoError(t, m.ConsumeBuySignal(api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTransfers}))
+
+	positions, err = paper.GetPositions(ctx)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "2.5", positions[0].Amount.String(), "transfers does not add to the position")
+
+	marketData.price = decimal.NewFromInt(50)
+	require.NoError(t, m.ConsumeBuySignal(api.BuySignal{Symbol: "SOL", Stage: api.SignalStageTradingLive}))
+
+	positions, err = paper.GetPositions(ctx)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "4.5", positions[0].Amount.String())
+
+	openOrders, err := paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 2)
+
+	entryPrice := positions[0].EntryPrice
+	assert.Equal(t, futures.OrderTypeTakeProfitMarket, openOrders[0].Type)
+	assert.Equal(t, roundToTickSize(priceChangedBy(entryPrice, 5), decimal.NewFromFloat(0.01)).String(), openOrders[0].StopPrice.String())
+	assert.Equal(t, futures.OrderTypeStopMarket, openOrders[1].Type)
+	assert.ElementsMatch(t, []int64{openOrders[0].OrderID, openOrders[1].OrderID}, m.getExitOrders()["SOLUSDT"])
+}
+
+func TestCreateShortPosition(t *testing.T) {
+	exchange := newFakeExchange(decimal.NewFromInt(40))
+
+	m, err := NewBinanceFuturesManager(
+		exchange,
+		zap.NewNop(),
+		WithWillExecuteOrder(true),
+		WithEachTradeAmountInUSD(100),
+		WithTakeProfitPriceChangedPercentage(5),
+		WithStopLossPriceChangedPercentage(10),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, m.createShortPosition("SOLUSDT"))
+	require.Len(t, exchange.orders, 3)
+
+	sellOrder := exchange.orders[0]
+	assert.Equal(t, futures.SideTypeSell, sellOrder.Side)
+	assert.Equal(t, futures.OrderTypeMarket, sellOrder.Type)
+	assert.Equal(t, "2.5", sellOrder.OrigQuantity.String())
+
+	takeProfitOrder := exchange.orders[1]
+	assert.Equal(t, futures.SideTypeBuy, takeProfitOrder.Side)
+	assert.Equal(t, futures.OrderTypeTakeProfitMarket, takeProfitOrder.Type)
+	assert.True(t, exchange.requests[1].ClosePosition)
+	assert.Equal(t, "38", takeProfitOrder.StopPrice.String())
+
+	stopLossOrder := exchange.orders[2]
+	assert.Equal(t, futures.SideTypeBuy, stopLossOrder.Side)
+	assert.Equal(t, futures.OrderTypeStopMarket, stopLossOrder.Type)
+	assert.Equal(t, "44", stopLossOrder.StopPrice.String())
+}
+
+func TestConsumeSellSignal(t *testing.T) {
+	newManager := func(opts ...FuturesOption) (*PaperExchange, *BinanceFuturesManager) {
+		paper := NewPaperExchange(
+			newFakeExchange(decimal.NewFromInt(40)),
+			zap.NewNop(),
+			WithPaperInitialBalanceInUSD(1000),
+			WithPaperSlippagePercentage(0),
+		)
+
+		m, err := NewBinanceFuturesManager(
+			paper,
+			zap.NewNop(),
+			append([]FuturesOption{WithWillExecuteOrder(true), WithEachTradeAmountInUSD(100)}, opts...)...,
+		)
+		require.NoError(t, err)
+		require.NoError(t, m.UpdateSupportedSymbols())
+
+		return paper, m
+	}
+
+	ctx := context.Background()
+	sellSignal := api.BuySignal{Symbol: "SOL", Side: api.SignalSideSell}
+
+	paper, m := newManager()
+	require.NoError(t, m.ConsumeSignal(sellSignal))
+
+	positions, err := paper.GetPositions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, positions, "short selling is disabled by default")
+
+	require.NoError(t, m.ConsumeSignal(api.BuySignal{Symbol: "SOL"}))
+	require.NoError(t, m.ConsumeSignal(sellSignal))
+
+	positions, err = paper.GetPositions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, positions, "the long position is closed")
+
+	openOrders, err := paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+	assert.Empty(t, openOrders)
+
+	paper, m = newManager(WithShortSelling(true))
+	require.NoError(t, m.ConsumeSignal(sellSignal))
+	require.NoError(t, m.ConsumeSignal(sellSignal))
+
+	positions, err = paper.GetPositions(ctx)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "-2.5", positions[0].Amount.String())
+
+	openOrders, err = paper.ListOpenOrders(ctx, "SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, openOrders, 1)
+	assert.Equal(t, futures.SideTypeBuy, openOrders[0].Side)
+	assert.Equal(t, "38", openOrders[0].StopPrice.String())
+
+	paper.UpdatePrice("SOLUSDT", decimal.NewFromInt(38))
+
+	positions, err = paper.GetPositions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+	assert.Equal(t, "5", paper.PnL().RealizedPnL.String())
+}
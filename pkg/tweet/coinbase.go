@@ -2,35 +2,69 @@ package tweet
 
 import (
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/dghubble/go-twitter/twitter"
-	"github.com/hbollon/go-edlib"
 	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
 )
 
 const (
 	CoinbaseProTwitterUserID = "720487892670410753"
 	newCoinListingPattern    = "Starting today, inbound transfers for XXX are now available in the regions where trading is supported. Traders cannot place orders and no orders will be filled. Trading will begin on or after 9AM PT on Mon 1/1 if liquidity conditions are met."
+	tradingLivePattern       = "Our XXX-USD, XXX-BTC & XXX-EUR order books are now in full-trading mode. Limit, market and stop orders are all now available."
+	tradingLiveSymbolRegex   = `\b([A-Z0-9]+)-USD\b`
 )
 
+// coinbasePatternLibrary is compiled once for the pattern checks below.
+var coinbasePatternLibrary = DefaultPatternLibrary() // nolint: gochecknoglobals
+
 func IsCoinbaseNewCoinListingPattern(text string) bool {
-	return edlib.JaroWinklerSimilarity(text, newCoinListingPattern) > 0.75 && strings.Contains(text, "transfer")
+	p, _ := coinbasePatternLibrary.Match(CoinbaseProTwitterUserID, text)
+
+	return p != nil && p.Stage == api.SignalStageTransfers
+}
+
+func IsCoinbaseTradingLivePattern(text string) bool {
+	p, _ := coinbasePatternLibrary.Match(CoinbaseProTwitterUserID, text)
+
+	return p != nil && p.Stage == api.SignalStageTradingLive
 }
 
-func handleCoinbaseTweetMessage(supportedCoins map[string]struct{}, t *twitter.Tweet, buySignalCh chan api.BuySignal) {
-	if t.User.IDStr == CoinbaseProTwitterUserID &&
-		!isReply(t) && !isRetweet(t) {
-		if IsCoinbaseNewCoinListingPattern(t.Text) {
-			symbols := extractSymbols(t.Text)
-			for _, s := range symbols {
-				if isExist(supportedCoins, s) {
-					buySignalCh <- api.BuySignal{
-						Symbol: s,
-						Source: getTweetURL(t.User.ScreenName, t.IDStr),
-					}
-				}
-			}
+func handleTweetMessage(
+	patternLibrary *PatternLibrary,
+	supportedCoins coin.Set,
+	t *twitter.Tweet,
+	signalCh chan api.Signal,
+) {
+	if t.User == nil || isReply(t) || isRetweet(t) {
+		return
+	}
+
+	p, similarity := patternLibrary.Match(t.User.IDStr, t.Text)
+	if p == nil {
+		return
+	}
+
+	detectedAt := time.Now()
+	// the time of the tweet is zero if it cannot be parsed
+	eventAt, _ := t.CreatedAtTime()
+
+	for _, s := range p.ExtractSymbols(t.Text) {
+		if supportedCoins.Contains(s) {
+			signal := api.NewSignal(api.BuySignal{
+				Symbol: s,
+				Source: getTweetURL(t.User.ScreenName, t.IDStr),
+				Stage:  p.Stage,
+				Side:   p.Side,
+			}, api.SourceKindTwitter, detectedAt)
+			signal.EventAt = eventAt
+			signal.Confidence = similarity
+			signal.RawText = t.Text
+			signal.Metadata = map[string]string{"pattern": p.Name, "account": t.User.IDStr}
+
+			signalCh <- signal
 		}
 	}
 }
@@ -3,8 +3,13 @@ package tweet
 import (
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsNewCoinListingPattern(t *testing.T) {
@@ -132,3 +137,77 @@ func TestExtractSymbols(t *testing.T) {
 		})
 	}
 }
+
+func TestCoinbaseTradingLivePattern(t *testing.T) {
+	testCases := []struct {
+		in      string
+		out     bool
+		symbols []string
+	}{
+		{
+			in:      "Our FORTH-USD, FORTH-EUR & FORTH-GBP order books are now in full-trading mode. Limit, market and stop orders are all now available.",
+			out:     true,
+			symbols: []string{"FORTH"},
+		},
+		{
+			in:      "Our GTC-USD, GTC-EUR, GTC-GBP, MLN-USD & AMP-USD order books are now in full-trading mode. Limit, market and stop orders are all now available.",
+			out:     true,
+			symbols: []string{"GTC", "MLN", "AMP"},
+		},
+		{
+			in:  "Our DOT-EUR order book will now enter limit-only mode. Limit orders can be placed and cancelled, and matches may occur. Market orders cannot be submitted. The order book will remain in limit-only mode for a minimum of 10 mins.",
+			out: false,
+		},
+		{
+			in:  "Starting today, inbound transfers for DOT are now available in the regions where trading is supported. Traders cannot place orders and no orders will be filled. Trading will begin on or after 9AM PT on Wednesday June 16, if liquidity conditions are met.",
+			out: false,
+		},
+	}
+	for i, tt := range testCases {
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tt.out, IsCoinbaseTradingLivePattern(tt.in))
+
+			if tt.out {
+				p, _ := DefaultPatternLibrary().Match(CoinbaseProTwitterUserID, tt.in)
+				assert.Equal(t, tt.symbols, p.ExtractSymbols(tt.in))
+			}
+		})
+	}
+}
+
+func TestHandleTweetMessageStage(t *testing.T) {
+	testCases := []struct {
+		text  string
+		stage api.SignalStage
+	}{
+		{
+			text:  "Starting today, inbound transfers for SOL are now available in the regions where trading is supported. Traders cannot place orders and no orders will be filled. Trading will begin on or after 9AM PT on Monday May 24, if liquidity conditions are met.",
+			stage: api.SignalStageTransfers,
+		},
+		{
+			text:  "Our SOL-USD, SOL-EUR & SOL-GBP order books are now in full-trading mode. Limit, market and stop orders are all now available.",
+			stage: api.SignalStageTradingLive,
+		},
+	}
+	for i, tt := range testCases {
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			ch := make(chan api.Signal, 1)
+			handleTweetMessage(DefaultPatternLibrary(), coin.Symbols{"SOL": {}}, &twitter.Tweet{
+				IDStr:     "1",
+				CreatedAt: "Mon May 24 16:00:00 +0000 2021",
+				Text:      tt.text,
+				User:      &twitter.User{IDStr: CoinbaseProTwitterUserID, ScreenName: "CoinbasePro"},
+			}, ch)
+
+			require.Len(t, ch, 1)
+			s := <-ch
+			assert.Equal(t, "SOL", s.Symbol)
+			assert.Equal(t, tt.stage, s.Stage)
+			assert.Equal(t, api.SourceKindTwitter, s.SourceKind)
+			assert.Greater(t, s.Confidence, 0.5, "the confidence is the similarity to the pattern")
+			assert.LessOrEqual(t, s.Confidence, 1.0)
+			assert.Equal(t, time.Date(2021, 5, 24, 16, 0, 0, 0, time.UTC), s.EventAt.UTC())
+			assert.Equal(t, tt.text, s.RawText)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package tweet
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReconnectInitialBackoff = 5 * time.Second
+	defaultReconnectMaxBackoff     = 320 * time.Second
+	defaultStallReconnectPercent   = 95
+)
+
+type ManagerOption interface {
+	apply(*managerOptions)
+}
+
+type managerOptions struct {
+	logger                  *zap.Logger
+	reconnectInitialBackoff time.Duration
+	reconnectMaxBackoff     time.Duration
+	stallReconnectPercent   int
+	stateChangeHandler      func(StreamState)
+	streamV2Client          *StreamV2Client
+	patternLibrary          *PatternLibrary
+}
+
+func newDefaultManagerOptions() managerOptions {
+	return managerOptions{
+		logger:                  zap.NewNop(),
+		reconnectInitialBackoff: defaultReconnectInitialBackoff,
+		reconnectMaxBackoff:     defaultReconnectMaxBackoff,
+		stallReconnectPercent:   defaultStallReconnectPercent,
+		stateChangeHandler:      func(StreamState) {},
+		patternLibrary:          DefaultPatternLibrary(),
+	}
+}
+
+type loggerOption struct {
+	logger *zap.Logger
+}
+
+func (c loggerOption) apply(opts *managerOptions) {
+	opts.logger = c.logger
+}
+
+func WithLogger(logger *zap.Logger) ManagerOption {
+	return loggerOption{logger: logger}
+}
+
+type reconnectBackoffOption struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+func (c reconnectBackoffOption) apply(opts *managerOptions) {
+	opts.reconnectInitialBackoff = c.initial
+	opts.reconnectMaxBackoff = c.max
+}
+
+// WithReconnectBackoff sets the range of the exponential backoff between reconnects of a dropped stream.
+func WithReconnectBackoff(initial time.Duration, max time.Duration) ManagerOption {
+	return reconnectBackoffOption{initial: initial, max: max}
+}
+
+type stallReconnectPercentOption int
+
+func (c stallReconnectPercentOption) apply(opts *managerOptions) {
+	opts.stallReconnectPercent = int(c)
+}
+
+// WithStallReconnectPercent reconnects the stream once a stall warning reports the queue at least this full.
+func WithStallReconnectPercent(percent int) ManagerOption {
+	return stallReconnectPercentOption(percent)
+}
+
+type stateChangeHandlerOption func(StreamState)
+
+func (c stateChangeHandlerOption) apply(opts *managerOptions) {
+	opts.stateChangeHandler = c
+}
+
+// WithStateChangeHandler is called whenever the connection state of the stream changes.
+func WithStateChangeHandler(f func(StreamState)) ManagerOption {
+	return stateChangeHandlerOption(f)
+}
+
+type streamV2ClientOption struct {
+	client *StreamV2Client
+}
+
+func (c streamV2ClientOption) apply(opts *managerOptions) {
+	opts.streamV2Client = c.client
+}
+
+// WithStreamV2Client streams the tweets from the API v2 filtered stream instead of the v1.1 filter stream.
+func WithStreamV2Client(client *StreamV2Client) ManagerOption {
+	return streamV2ClientOption{client: client}
+}
+
+type patternLibraryOption struct {
+	library *PatternLibrary
+}
+
+func (c patternLibraryOption) apply(opts *managerOptions) {
+	opts.patternLibrary = c.library
+}
+
+// WithPatternLibrary replaces the built in Coinbase listing pattern with the given library.
+func WithPatternLibrary(library *PatternLibrary) ManagerOption {
+	return patternLibraryOption{library: library}
+}
+
+type V2Option interface {
+	apply(*v2Options)
+}
+
+type v2Options struct {
+	baseURL          string
+	httpClient       *http.Client
+	keepAliveTimeout time.Duration
+}
+
+func newDefaultV2Options() v2Options {
+	return v2Options{
+		baseURL:          defaultV2BaseURL,
+		httpClient:       &http.Client{},
+		keepAliveTimeout: defaultV2KeepAliveTimeout,
+	}
+}
+
+type v2BaseURLOption string
+
+func (c v2BaseURLOption) apply(opts *v2Options) {
+	opts.baseURL = string(c)
+}
+
+func WithV2BaseURL(baseURL string) V2Option {
+	return v2BaseURLOption(baseURL)
+}
+
+type v2HTTPClientOption struct {
+	httpClient *http.Client
+}
+
+func (c v2HTTPClientOption) apply(opts *v2Options) {
+	opts.httpClient = c.httpClient
+}
+
+// WithV2HTTPClient sets the client of the requests, it must not have a timeout as the stream is long lived.
+func WithV2HTTPClient(httpClient *http.Client) V2Option {
+	return v2HTTPClientOption{httpClient: httpClient}
+}
+
+type v2KeepAliveTimeoutOption time.Duration
+
+func (c v2KeepAliveTimeoutOption) apply(opts *v2Options) {
+	opts.keepAliveTimeout = time.Duration(c)
+}
+
+// WithV2KeepAliveTimeout drops the stream if nothing arrives for the duration, twitter sends a keep alive every 20 seconds.
+func WithV2KeepAliveTimeout(d time.Duration) V2Option {
+	return v2KeepAliveTimeoutOption(d)
+}
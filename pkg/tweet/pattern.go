@@ -0,0 +1,308 @@
+package tweet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hbollon/go-edlib"
+	"github.com/lht102/ctrade/api"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ExtractionUppercaseRun = "uppercase_run"
+	ExtractionRegex        = "regex"
+
+	defaultPatternAlgorithm = "jaro_winkler"
+	defaultPatternThreshold = 0.75
+)
+
+var (
+	errUnknownAlgorithm      = errors.New("unknown similarity algorithm")
+	errUnknownExtraction     = errors.New("unknown symbol extraction rule")
+	errUnknownPatternFormat  = errors.New("unknown pattern file format")
+	errEmptyPatternTemplates = errors.New("pattern without templates")
+)
+
+// nolint: gochecknoglobals
+var similarityAlgorithms = map[string]edlib.Algorithm{
+	"levenshtein":             edlib.Levenshtein,
+	"damerau_levenshtein":     edlib.DamerauLevenshtein,
+	"osa_damerau_levenshtein": edlib.OSADamerauLevenshtein,
+	"lcs":                     edlib.Lcs,
+	"jaro":                    edlib.Jaro,
+	"jaro_winkler":            edlib.JaroWinkler,
+	"cosine":                  edlib.Cosine,
+}
+
+// PatternLibrary is the set of announcement patterns that turn a tweet into buy signals.
+type PatternLibrary struct {
+	Patterns []Pattern `json:"patterns" yaml:"patterns"`
+}
+
+// Pattern matches a tweet of one of the accounts when it is similar enough to any of the templates
+// and contains every keyword.
+type Pattern struct {
+	Name       string         `json:"name" yaml:"name"`
+	Accounts   []string       `json:"accounts" yaml:"accounts"`
+	Templates  []string       `json:"templates" yaml:"templates"`
+	Algorithm  string         `json:"algorithm" yaml:"algorithm"`
+	Threshold  float32        `json:"threshold" yaml:"threshold"`
+	Keywords   []string       `json:"keywords" yaml:"keywords"`
+	Extraction ExtractionRule `json:"extraction" yaml:"extraction"`
+	// Stage is carried by the signals of the pattern, e.g. transfers or trading_live.
+	Stage api.SignalStage `json:"stage" yaml:"stage"`
+	// Side is carried by the signals of the pattern, sell for a delisting or suspension announcement.
+	Side api.SignalSide `json:"side" yaml:"side"`
+
+	algorithm edlib.Algorithm
+	regex     *regexp.Regexp
+}
+
+// ExtractionRule picks the symbols out of a matched tweet. uppercase_run takes the first run of
+// upper case words, regex takes the first capture group, or the whole match without a group, of every match.
+type ExtractionRule struct {
+	Type  string `json:"type" yaml:"type"`
+	Regex string `json:"regex" yaml:"regex"`
+}
+
+// DefaultPatternLibrary matches the Coinbase Pro inbound transfers and trading live tweets of a new coin.
+func DefaultPatternLibrary() *PatternLibrary {
+	l := &PatternLibrary{
+		Patterns: []Pattern{
+			{
+				Name:      "coinbase_inbound_transfers",
+				Accounts:  []string{CoinbaseProTwitterUserID},
+				Templates: []string{newCoinListingPattern},
+				Algorithm: defaultPatternAlgorithm,
+				Threshold: defaultPatternThreshold,
+				Keywords:  []string{"transfer"},
+				Extraction: ExtractionRule{
+					Type: ExtractionUppercaseRun,
+				},
+				Stage: api.SignalStageTransfers,
+			},
+			{
+				Name:      "coinbase_trading_live",
+				Accounts:  []string{CoinbaseProTwitterUserID},
+				Templates: []string{tradingLivePattern},
+				Algorithm: defaultPatternAlgorithm,
+				Threshold: defaultPatternThreshold,
+				Keywords:  []string{"full-trading mode"},
+				Extraction: ExtractionRule{
+					Type:  ExtractionRegex,
+					Regex: tradingLiveSymbolRegex,
+				},
+				Stage: api.SignalStageTradingLive,
+			},
+		},
+	}
+
+	if err := l.compile(); err != nil {
+		panic(err)
+	}
+
+	return l
+}
+
+// NewPatternLibrary validates the patterns and fills in the defaults of the unset fields.
+func NewPatternLibrary(patterns ...Pattern) (*PatternLibrary, error) {
+	l := &PatternLibrary{
+		Patterns: patterns,
+	}
+
+	if err := l.compile(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// LoadPatternLibrary reads the library from a .yaml, .yml or .json file, an unknown field is rejected in both formats.
+func LoadPatternLibrary(path string) (*PatternLibrary, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pattern file: %w", err)
+	}
+
+	l := &PatternLibrary{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(b, l)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(l)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownPatternFormat, path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal pattern file: %w", err)
+	}
+
+	if err := l.compile(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Match returns the first pattern of the account that matches the text and the similarity of the text to its
+// closest template, nil if there is none.
+func (l *PatternLibrary) Match(accountID string, text string) (*Pattern, float64) {
+	for i := range l.Patterns {
+		p := &l.Patterns[i]
+		if !p.hasAccount(accountID) {
+			continue
+		}
+
+		if similarity, ok := p.matches(text); ok {
+			return p, similarity
+		}
+	}
+
+	return nil, 0
+}
+
+// Accounts returns the accounts named by the patterns in alphabetical order, a pattern without accounts
+// matches any account and adds none.
+func (l *PatternLibrary) Accounts() []string {
+	seen := make(map[string]struct{})
+	res := []string{}
+
+	for _, p := range l.Patterns {
+		for _, a := range p.Accounts {
+			if _, ok := seen[a]; ok {
+				continue
+			}
+
+			seen[a] = struct{}{}
+			res = append(res, a)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+func (l *PatternLibrary) compile() error {
+	for i := range l.Patterns {
+		p := &l.Patterns[i]
+
+		if len(p.Templates) == 0 {
+			return fmt.Errorf("%w: %s", errEmptyPatternTemplates, p.Name)
+		}
+
+		if p.Algorithm == "" {
+			p.Algorithm = defaultPatternAlgorithm
+		}
+
+		algo, ok := similarityAlgorithms[p.Algorithm]
+		if !ok {
+			return fmt.Errorf("%w: %s", errUnknownAlgorithm, p.Algorithm)
+		}
+
+		p.algorithm = algo
+
+		if p.Side != "" {
+			if _, err := api.ParseSignalSide(string(p.Side)); err != nil {
+				return fmt.Errorf("pattern %s: %w", p.Name, err)
+			}
+		}
+
+		if p.Stage != "" {
+			if _, err := api.ParseSignalStage(string(p.Stage)); err != nil {
+				return fmt.Errorf("pattern %s: %w", p.Name, err)
+			}
+		}
+
+		if p.Threshold == 0 {
+			p.Threshold = defaultPatternThreshold
+		}
+
+		switch p.Extraction.Type {
+		case "":
+			p.Extraction.Type = ExtractionUppercaseRun
+		case ExtractionUppercaseRun:
+		case ExtractionRegex:
+			re, err := regexp.Compile(p.Extraction.Regex)
+			if err != nil {
+				return fmt.Errorf("compile extraction regex of %s: %w", p.Name, err)
+			}
+
+			p.regex = re
+		default:
+			return fmt.Errorf("%w: %s", errUnknownExtraction, p.Extraction.Type)
+		}
+	}
+
+	return nil
+}
+
+func (p *Pattern) hasAccount(accountID string) bool {
+	if len(p.Accounts) == 0 {
+		return true
+	}
+
+	for _, a := range p.Accounts {
+		if a == accountID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches returns the highest similarity of the text to the templates, and whether it is above the threshold.
+func (p *Pattern) matches(text string) (float64, bool) {
+	for _, k := range p.Keywords {
+		if !strings.Contains(text, k) {
+			return 0, false
+		}
+	}
+
+	var res float32
+
+	for _, t := range p.Templates {
+		similarity, err := edlib.StringsSimilarity(text, t, p.algorithm)
+		if err == nil && similarity > res {
+			res = similarity
+		}
+	}
+
+	return float64(res), res > p.Threshold
+}
+
+// ExtractSymbols returns the symbols in the text by the extraction rule of the pattern, without duplicates.
+func (p *Pattern) ExtractSymbols(text string) []string {
+	if p.regex == nil {
+		return extractSymbols(text)
+	}
+
+	res := []string{}
+	seen := make(map[string]struct{})
+
+	for _, m := range p.regex.FindAllStringSubmatch(text, -1) {
+		s := m[0]
+		if len(m) > 1 {
+			s = m[1]
+		}
+
+		if _, ok := seen[s]; !ok {
+			seen[s] = struct{}{}
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
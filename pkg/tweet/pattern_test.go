@@ -0,0 +1,156 @@
+package tweet
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPatternYAML = `
+patterns:
+  - name: coinbase_trading_live
+    accounts: ["720487892670410753"]
+    templates:
+      - "XXX is now available on Coinbase Pro. Trading is now live."
+      - "XXX and YYY are now available on Coinbase Pro. Trading is now live."
+    algorithm: levenshtein
+    threshold: 0.6
+    keywords: ["Trading is now live"]
+    stage: trading_live
+  - name: any_listing
+    templates: ["Will list $XXX"]
+    algorithm: jaro
+    threshold: 0.7
+    keywords: ["Will list"]
+    extraction:
+      type: regex
+      regex: '\$([A-Z0-9]+)'
+  - name: any_delisting
+    templates: ["Will delist $XXX"]
+    algorithm: jaro
+    threshold: 0.7
+    keywords: ["delist"]
+    extraction:
+      type: regex
+      regex: '\$([A-Z0-9]+)'
+    side: sell
+`
+
+func writePatternFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadPatternLibrary(t *testing.T) {
+	l, err := LoadPatternLibrary(writePatternFile(t, "patterns.yaml", testPatternYAML))
+	require.NoError(t, err)
+	assert.Equal(t, api.SignalStageTradingLive, l.Patterns[0].Stage)
+	assert.Equal(t, []string{"720487892670410753"}, l.Accounts())
+
+	testCases := []struct {
+		account string
+		text    string
+		symbols []string
+		matched bool
+		side    api.SignalSide
+	}{
+		{
+			account: CoinbaseProTwitterUserID,
+			text:    "AXS is now available on Coinbase Pro. Trading is now live.",
+			symbols: []string{"AXS"},
+			matched: true,
+		},
+		{
+			account: CoinbaseProTwitterUserID,
+			text:    "AXS is now available on Coinbase Pro. Trading begins soon.",
+		},
+		{
+			account: "1",
+			text:    "AXS is now available on Coinbase Pro. Trading is now live.",
+		},
+		{
+			account: "1",
+			text:    "Will list $ABC",
+			symbols: []string{"ABC"},
+			matched: true,
+		},
+		{
+			account: "1",
+			text:    "Will delist $ABC",
+			symbols: []string{"ABC"},
+			matched: true,
+			side:    api.SignalSideSell,
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			p, _ := l.Match(tc.account, tc.text)
+			require.Equal(t, tc.matched, p != nil)
+
+			if p != nil {
+				assert.Equal(t, tc.symbols, p.ExtractSymbols(tc.text))
+				assert.Equal(t, tc.side, p.Side)
+			}
+		})
+	}
+}
+
+func TestLoadPatternLibraryJSON(t *testing.T) {
+	l, err := LoadPatternLibrary(writePatternFile(t, "patterns.json", `{"patterns":[{"name":"a","templates":["New listing: XXX"]}]}`))
+	require.NoError(t, err)
+
+	require.Len(t, l.Patterns, 1)
+	assert.Equal(t, defaultPatternAlgorithm, l.Patterns[0].Algorithm)
+	assert.Equal(t, float32(defaultPatternThreshold), l.Patterns[0].Threshold)
+	assert.Equal(t, ExtractionUppercaseRun, l.Patterns[0].Extraction.Type)
+
+	p, similarity := l.Match("1", "New listing: DOGE")
+	require.NotNil(t, p)
+	assert.Equal(t, []string{"DOGE"}, p.ExtractSymbols("New listing: DOGE"))
+	assert.Greater(t, similarity, float64(defaultPatternThreshold))
+	assert.Less(t, similarity, 1.0)
+}
+
+func TestLoadPatternLibraryInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		err     error
+	}{
+		{name: "a.yaml", content: "patterns:\n  - name: a\n", err: errEmptyPatternTemplates},
+		{name: "a.yaml", content: "patterns:\n  - templates: [a]\n    algorithm: foo\n", err: errUnknownAlgorithm},
+		{name: "a.json", content: `{"patterns":[{"templates":["a"],"extraction":{"type":"foo"}}]}`, err: errUnknownExtraction},
+		{name: "a.txt", content: "", err: errUnknownPatternFormat},
+		{name: "a.json", content: `{"patterns":[{"templates":["a"],"side":"hold"}]}`, err: api.ErrUnknownSignalSide},
+		{name: "a.json", content: `{"patterns":[{"templates":["a"],"stage":"rumour"}]}`, err: api.ErrUnknownSignalStage},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			_, err := LoadPatternLibrary(writePatternFile(t, tc.name, tc.content))
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestLoadPatternLibraryUnknownField(t *testing.T) {
+	_, err := LoadPatternLibrary(writePatternFile(t, "a.yaml", "patterns:\n  - templates: [a]\n    keyword: [a]\n"))
+	assert.Error(t, err)
+
+	_, err = LoadPatternLibrary(writePatternFile(t, "a.json", `{"patterns":[{"templates":["a"],"keyword":["a"]}]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package tweet
+
+import (
+	"math/rand"
+	"time"
+)
+
+type StreamState int32
+
+const (
+	StreamStateDisconnected StreamState = iota
+	StreamStateConnecting
+	StreamStateConnected
+	StreamStateStalled
+	StreamStateStopped
+)
+
+func (s StreamState) String() string {
+	switch s {
+	case StreamStateDisconnected:
+		return "disconnected"
+	case StreamStateConnecting:
+		return "connecting"
+	case StreamStateConnected:
+		return "connected"
+	case StreamStateStalled:
+		return "stalled"
+	case StreamStateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// backoff doubles the wait after every attempt up to max, each wait is randomized between half and the full interval.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+
+	current time.Duration
+}
+
+func newBackoff(initial time.Duration, max time.Duration) *backoff {
+	return &backoff{
+		initial: initial,
+		max:     max,
+		current: initial,
+	}
+}
+
+func (b *backoff) next() time.Duration {
+	d := b.current
+
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+
+	half := int64(d / 2) // nolint: gomnd
+	if half <= 0 {
+		return d
+	}
+
+	return time.Duration(half + rand.Int63n(half+1)) // nolint: gosec
+}
+
+func (b *backoff) reset() {
+	b.current = b.initial
+}
@@ -0,0 +1,121 @@
+package tweet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dghubble/go-twitter/twitter"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newStubTwitterClient(t *testing.T, handler http.HandlerFunc) *twitter.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	target, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	return twitter.NewClient(&http.Client{Transport: rewriteTransport{target: target}})
+}
+
+func writeStreamMessage(w http.ResponseWriter, msg string) {
+	fmt.Fprint(w, msg+"\r\n")
+	w.(http.Flusher).Flush()
+}
+
+func TestSubscribeTweetChannelReconnect(t *testing.T) {
+	var requestCount int32
+
+	client := newStubTwitterClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requestCount, 1)
+
+		w.WriteHeader(http.StatusOK)
+
+		switch n {
+		case 1:
+			writeStreamMessage(w, `{"id_str":"1","text":"first","retweet_count":0}`)
+		case 2:
+			writeStreamMessage(w, `{"warning":{"code":"FALLING_BEHIND","message":"behind","percent_full":99}}`)
+			<-r.Context().Done()
+		default:
+			writeStreamMessage(w, `{"id_str":"2","text":"second","retweet_count":0}`)
+			<-r.Context().Done()
+		}
+	})
+
+	states := make(chan StreamState, 100)
+	m := NewManager(
+		client,
+		[]string{"1"},
+		coin.Symbols{},
+		WithReconnectBackoff(time.Millisecond, 10*time.Millisecond),
+		WithStateChangeHandler(func(s StreamState) { states <- s }),
+	)
+
+	tweetCh, err := m.SubscribeTweetChannel()
+	require.NoError(t, err)
+
+	tweets := []string{}
+	for len(tweets) < 2 {
+		select {
+		case tw := <-tweetCh:
+			tweets = append(tweets, tw.Text)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for tweets")
+		}
+	}
+
+	assert.Equal(t, []string{"first", "second"}, tweets)
+	assert.Equal(t, StreamStateConnected, m.State())
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&requestCount), int32(3))
+
+	m.Stop()
+
+	_, ok := <-tweetCh
+	assert.False(t, ok)
+	assert.Equal(t, StreamStateStopped, m.State())
+
+	close(states)
+
+	seen := map[StreamState]bool{}
+	for s := range states {
+		seen[s] = true
+	}
+
+	assert.True(t, seen[StreamStateStalled])
+	assert.True(t, seen[StreamStateDisconnected])
+}
+
+func TestBackoff(t *testing.T) {
+	b := newBackoff(time.Second, 4*time.Second)
+
+	for _, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d := b.next()
+		assert.GreaterOrEqual(t, d, max/2)
+		assert.LessOrEqual(t, d, max)
+	}
+
+	b.reset()
+	assert.LessOrEqual(t, b.next(), time.Second)
+}
@@ -1,81 +1,116 @@
 package tweet
 
 import (
+	"context"
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/dghubble/go-twitter/twitter"
 	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
+	"go.uber.org/zap"
 )
 
+const SourceName = "twitter"
+
+// stableConnectionDuration is how long a connection has to last before the reconnect backoff starts over.
+const stableConnectionDuration = time.Minute
+
 type Manager struct {
 	twitterClient         *twitter.Client
 	trackedTwitterUserIDs []string
-	supportedCoins        map[string]struct{}
+	supportedCoins        coin.Set
+	managerOpts           managerOptions
 
 	done            chan struct{}
 	usedStreamCount int32
+	state           int32
 }
 
-func NewManager(twitterClient *twitter.Client, twitterUserIDs []string, supportedCoins map[string]struct{}) *Manager {
+func NewManager(
+	twitterClient *twitter.Client,
+	twitterUserIDs []string,
+	supportedCoins coin.Set,
+	opts ...ManagerOption,
+) *Manager {
+	options := newDefaultManagerOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
 	return &Manager{
 		twitterClient:         twitterClient,
 		trackedTwitterUserIDs: twitterUserIDs,
 		supportedCoins:        supportedCoins,
+		managerOpts:           options,
 		done:                  make(chan struct{}),
 	}
 }
 
-func (m *Manager) SubscribeBuySignalChannel() (<-chan api.BuySignal, error) {
+// Name identifies the manager as a signal source.
+func (m *Manager) Name() string {
+	return SourceName
+}
+
+// Start subscribes the signal channel so that the manager can be run as a signal source.
+func (m *Manager) Start() (<-chan api.Signal, error) {
+	return m.SubscribeSignalChannel()
+}
+
+func (m *Manager) SubscribeSignalChannel() (<-chan api.Signal, error) {
 	twitterCh, err := m.SubscribeTweetChannel()
 	if err != nil {
 		return nil, err
 	}
 
-	buySignalCh := make(chan api.BuySignal)
+	signalCh := make(chan api.Signal)
 
 	go func() {
-		defer close(buySignalCh)
+		defer close(signalCh)
 
 		for t := range twitterCh {
-			handleCoinbaseTweetMessage(m.supportedCoins, t, buySignalCh)
+			handleTweetMessage(m.managerOpts.patternLibrary, m.supportedCoins, t, signalCh)
 		}
 	}()
 
-	return buySignalCh, nil
+	return signalCh, nil
 }
 
+// SubscribeTweetChannel streams the tweets of the tracked users. The stream is reconnected with backoff
+// whenever it drops or falls behind, the returned channel is only closed after Stop.
 func (m *Manager) SubscribeTweetChannel() (<-chan *twitter.Tweet, error) {
-	filterParams := &twitter.StreamFilterParams{
-		Follow:        m.trackedTwitterUserIDs,
-		StallWarnings: twitter.Bool(true),
+	if c := m.managerOpts.streamV2Client; c != nil {
+		if err := c.SyncFollowRules(context.Background(), m.trackedTwitterUserIDs); err != nil {
+			return nil, fmt.Errorf("sync stream rules: %w", err)
+		}
 	}
 
-	stream, err := m.twitterClient.Streams.Filter(filterParams)
+	m.setState(StreamStateConnecting)
+
+	stream, err := m.openStream()
 	if err != nil {
-		return nil, fmt.Errorf("twitter filterd stream: %w", err)
+		m.setState(StreamStateDisconnected)
+
+		return nil, err
 	}
 
+	m.setState(StreamStateConnected)
+
 	tweetCh := make(chan *twitter.Tweet)
-	demux := twitter.NewSwitchDemux()
-	demux.Tweet = func(tweet *twitter.Tweet) {
-		tweetCh <- tweet
-	}
 
-	go func() {
-		defer close(tweetCh)
-		atomic.AddInt32(&m.usedStreamCount, 1)
-		demux.HandleChan(stream.Messages)
-	}()
+	atomic.AddInt32(&m.usedStreamCount, 1)
 
-	go func() {
-		<-m.done
-		stream.Stop()
-	}()
+	go m.runStream(stream, tweetCh)
 
 	return tweetCh, nil
 }
 
+// State returns the connection state of the latest subscribed stream.
+func (m *Manager) State() StreamState {
+	return StreamState(atomic.LoadInt32(&m.state))
+}
+
 func (m *Manager) Stop() {
 	for atomic.LoadInt32(&m.usedStreamCount) > 0 {
 		atomic.AddInt32(&m.usedStreamCount, -1)
@@ -83,10 +118,152 @@ func (m *Manager) Stop() {
 	}
 }
 
-func isExist(set map[string]struct{}, s string) bool {
-	_, exist := set[s]
+// streamConn is a single connection to either the v1.1 or the v2 stream.
+type streamConn struct {
+	messages <-chan interface{}
+	stop     func()
+}
+
+func (m *Manager) openStream() (streamConn, error) {
+	if c := m.managerOpts.streamV2Client; c != nil {
+		stream, err := c.Stream(context.Background())
+		if err != nil {
+			return streamConn{}, err
+		}
+
+		return streamConn{messages: stream.Messages, stop: stream.Stop}, nil
+	}
+
+	filterParams := &twitter.StreamFilterParams{
+		Follow:        m.trackedTwitterUserIDs,
+		StallWarnings: twitter.Bool(true),
+	}
+
+	stream, err := m.twitterClient.Streams.Filter(filterParams)
+	if err != nil {
+		return streamConn{}, fmt.Errorf("twitter filterd stream: %w", err)
+	}
+
+	return streamConn{messages: stream.Messages, stop: stream.Stop}, nil
+}
+
+func (m *Manager) runStream(stream streamConn, tweetCh chan<- *twitter.Tweet) {
+	defer close(tweetCh)
+
+	logger := m.managerOpts.logger
+	b := newBackoff(m.managerOpts.reconnectInitialBackoff, m.managerOpts.reconnectMaxBackoff)
+
+	for {
+		connectedAt := time.Now()
+		stopped := m.consumeStream(stream, tweetCh)
+
+		stopStream(stream)
+
+		if stopped {
+			m.setState(StreamStateStopped)
+
+			return
+		}
+
+		m.setState(StreamStateDisconnected)
+
+		if time.Since(connectedAt) >= stableConnectionDuration {
+			b.reset()
+		}
+
+		for {
+			wait := b.next()
+			logger.Warn("Twitter stream disconnected, reconnecting", zap.Duration("wait", wait))
+
+			select {
+			case <-m.done:
+				m.setState(StreamStateStopped)
+
+				return
+			case <-time.After(wait):
+			}
+
+			m.setState(StreamStateConnecting)
+
+			s, err := m.openStream()
+			if err == nil {
+				stream = s
+
+				break
+			}
+
+			logger.Error("Fail to reconnect twitter stream", zap.Error(err))
+			m.setState(StreamStateDisconnected)
+		}
+
+		logger.Info("Twitter stream reconnected")
+		m.setState(StreamStateConnected)
+	}
+}
+
+// consumeStream forwards the tweets of the stream until it ends or has to be reconnected,
+// it returns true if the manager is stopped.
+func (m *Manager) consumeStream(stream streamConn, tweetCh chan<- *twitter.Tweet) bool {
+	logger := m.managerOpts.logger
+
+	for {
+		var (
+			msg interface{}
+			ok  bool
+		)
+
+		select {
+		case <-m.done:
+			return true
+		case msg, ok = <-stream.messages:
+			if !ok {
+				return false
+			}
+		}
+
+		switch v := msg.(type) {
+		case *twitter.Tweet:
+			m.setState(StreamStateConnected)
+
+			select {
+			case <-m.done:
+				return true
+			case tweetCh <- v:
+			}
+		case *twitter.StallWarning:
+			logger.Warn(
+				"Twitter stream stall warning",
+				zap.String("code", v.Code),
+				zap.String("message", v.Message),
+				zap.Int("percentFull", v.PercentFull),
+			)
+			m.setState(StreamStateStalled)
+
+			if v.PercentFull >= m.managerOpts.stallReconnectPercent {
+				return false
+			}
+		case *twitter.StreamDisconnect:
+			logger.Warn("Twitter stream disconnect notice", zap.Int64("code", v.Code), zap.String("reason", v.Reason))
+		case error:
+			logger.Error("Twitter stream error", zap.Error(v))
+		}
+	}
+}
+
+func (m *Manager) setState(state StreamState) {
+	if StreamState(atomic.SwapInt32(&m.state, int32(state))) != state {
+		m.managerOpts.stateChangeHandler(state)
+	}
+}
+
+// stopStream drains the messages left in the stream so that stopping it never blocks on an unread message.
+func stopStream(stream streamConn) {
+	go func() {
+		for range stream.messages {
+		}
+	}()
 
-	return exist
+	stream.stop()
 }
 
 func isRetweet(t *twitter.Tweet) bool {
@@ -0,0 +1,357 @@
+package tweet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dghubble/go-twitter/twitter"
+)
+
+const (
+	defaultV2BaseURL          = "https://api.twitter.com"
+	defaultV2KeepAliveTimeout = 40 * time.Second
+	streamRulesPath           = "/2/tweets/search/stream/rules"
+	streamPath                = "/2/tweets/search/stream"
+	managedRuleTag            = "ctrade"
+)
+
+var errUnexpectedStatusCode = errors.New("unexpected status code")
+
+type StreamRule struct {
+	ID    string `json:"id,omitempty"`
+	Value string `json:"value"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// StreamV2Client talks to the Twitter API v2 filtered stream with an app bearer token.
+type StreamV2Client struct {
+	bearerToken string
+	v2Opts      v2Options
+}
+
+func NewStreamV2Client(bearerToken string, opts ...V2Option) *StreamV2Client {
+	options := newDefaultV2Options()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &StreamV2Client{
+		bearerToken: bearerToken,
+		v2Opts:      options,
+	}
+}
+
+func (c *StreamV2Client) GetRules(ctx context.Context) ([]StreamRule, error) {
+	var resp struct {
+		Data []StreamRule `json:"data"`
+	}
+
+	if err := c.doJSON(ctx, http.MethodGet, streamRulesPath, nil, &resp); err != nil {
+		return nil, fmt.Errorf("get stream rules: %w", err)
+	}
+
+	return resp.Data, nil
+}
+
+func (c *StreamV2Client) AddRules(ctx context.Context, rules []StreamRule) ([]StreamRule, error) {
+	var resp struct {
+		Data []StreamRule `json:"data"`
+	}
+
+	req := struct {
+		Add []StreamRule `json:"add"`
+	}{
+		Add: rules,
+	}
+
+	if err := c.doJSON(ctx, http.MethodPost, streamRulesPath, req, &resp); err != nil {
+		return nil, fmt.Errorf("add stream rules: %w", err)
+	}
+
+	return resp.Data, nil
+}
+
+func (c *StreamV2Client) DeleteRules(ctx context.Context, ids []string) error {
+	req := struct {
+		Delete struct {
+			IDs []string `json:"ids"`
+		} `json:"delete"`
+	}{}
+	req.Delete.IDs = ids
+
+	if err := c.doJSON(ctx, http.MethodPost, streamRulesPath, req, nil); err != nil {
+		return fmt.Errorf("delete stream rules: %w", err)
+	}
+
+	return nil
+}
+
+// SyncFollowRules makes the rules tagged by this client follow exactly the given user ids.
+func (c *StreamV2Client) SyncFollowRules(ctx context.Context, userIDs []string) error {
+	rules, err := c.GetRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		wanted["from:"+id] = struct{}{}
+	}
+
+	staleIDs := []string{}
+
+	for _, r := range rules {
+		if r.Tag != managedRuleTag {
+			continue
+		}
+
+		if _, ok := wanted[r.Value]; ok {
+			delete(wanted, r.Value)
+
+			continue
+		}
+
+		staleIDs = append(staleIDs, r.ID)
+	}
+
+	if len(staleIDs) > 0 {
+		if err := c.DeleteRules(ctx, staleIDs); err != nil {
+			return err
+		}
+	}
+
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	newRules := make([]StreamRule, 0, len(wanted))
+	for _, id := range userIDs {
+		if _, ok := wanted["from:"+id]; ok {
+			newRules = append(newRules, StreamRule{Value: "from:" + id, Tag: managedRuleTag})
+		}
+	}
+
+	_, err = c.AddRules(ctx, newRules)
+
+	return err
+}
+
+// StreamV2 is a connection to the filtered stream. Like twitter.Stream, Messages receives *twitter.Tweet
+// converted from the v2 payload and errors, and is closed when the connection ends.
+type StreamV2 struct {
+	Messages chan interface{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (s *StreamV2) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Stream connects to the filtered stream, the connection is dropped if nothing, not even a keep alive,
+// arrives within the keep alive timeout.
+func (c *StreamV2Client) Stream(ctx context.Context) (*StreamV2, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	req, err := c.newRequest(ctx, http.MethodGet, streamPath, nil)
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Set("expansions", "author_id")
+	q.Set("tweet.fields", "author_id,in_reply_to_user_id,referenced_tweets")
+	q.Set("user.fields", "username")
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.v2Opts.httpClient.Do(req)
+	if err != nil {
+		cancel()
+
+		return nil, fmt.Errorf("connect filtered stream: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		cancel()
+
+		return nil, fmt.Errorf("connect filtered stream: %w", newStatusError(resp))
+	}
+
+	s := &StreamV2{
+		Messages: make(chan interface{}),
+		cancel:   cancel,
+	}
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer close(s.Messages)
+		defer resp.Body.Close()
+
+		c.receive(ctx, cancel, resp.Body, s.Messages)
+	}()
+
+	return s, nil
+}
+
+func (c *StreamV2Client) receive(ctx context.Context, cancel context.CancelFunc, body io.Reader, messages chan<- interface{}) {
+	keepAlive := time.AfterFunc(c.v2Opts.keepAliveTimeout, cancel)
+	defer keepAlive.Stop()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // nolint: gomnd
+
+	for scanner.Scan() {
+		keepAlive.Reset(c.v2Opts.keepAliveTimeout)
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg interface{}
+
+		t, err := parseStreamV2Tweet(line)
+		if err != nil {
+			msg = err
+		} else {
+			msg = t
+		}
+
+		select {
+		case messages <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type streamV2Payload struct {
+	Data struct {
+		ID               string `json:"id"`
+		Text             string `json:"text"`
+		AuthorID         string `json:"author_id"`
+		InReplyToUserID  string `json:"in_reply_to_user_id"`
+		ReferencedTweets []struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"referenced_tweets"`
+	} `json:"data"`
+	Includes struct {
+		Users []struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"users"`
+	} `json:"includes"`
+}
+
+// parseStreamV2Tweet converts a v2 stream payload into the v1.1 tweet model used by the handlers.
+func parseStreamV2Tweet(b []byte) (*twitter.Tweet, error) {
+	var p streamV2Payload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("unmarshal stream tweet: %w", err)
+	}
+
+	t := &twitter.Tweet{
+		IDStr: p.Data.ID,
+		Text:  p.Data.Text,
+		User: &twitter.User{
+			IDStr: p.Data.AuthorID,
+		},
+		InReplyToUserIDStr: p.Data.InReplyToUserID,
+	}
+	t.ID, _ = strconv.ParseInt(p.Data.ID, 10, 64)
+	t.User.ID, _ = strconv.ParseInt(p.Data.AuthorID, 10, 64)
+	t.InReplyToUserID, _ = strconv.ParseInt(p.Data.InReplyToUserID, 10, 64)
+
+	for _, u := range p.Includes.Users {
+		if u.ID == p.Data.AuthorID {
+			t.User.ScreenName = u.Username
+		}
+	}
+
+	for _, r := range p.Data.ReferencedTweets {
+		switch r.Type {
+		case "retweeted":
+			t.RetweetedStatus = &twitter.Tweet{IDStr: r.ID}
+		case "quoted":
+			t.QuotedStatusIDStr = r.ID
+		}
+	}
+
+	return t, nil
+}
+
+func (c *StreamV2Client) doJSON(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+
+		body = bytes.NewReader(b)
+	}
+
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.v2Opts.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return newStatusError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+func (c *StreamV2Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.v2Opts.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+func newStatusError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) // nolint: gomnd
+
+	return fmt.Errorf("%w %d: %s", errUnexpectedStatusCode, resp.StatusCode, bytes.TrimSpace(b))
+}
@@ -0,0 +1,182 @@
+package tweet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/lht102/ctrade/pkg/coin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubStreamV2Server struct {
+	mu       sync.Mutex
+	rules    map[string]StreamRule
+	nextID   int
+	payloads []string
+}
+
+func (s *stubStreamV2Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == streamRulesPath && r.Method == http.MethodGet:
+		data := []StreamRule{}
+		for _, rule := range s.rules {
+			data = append(data, rule)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	case r.URL.Path == streamRulesPath && r.Method == http.MethodPost:
+		var req struct {
+			Add    []StreamRule `json:"add"`
+			Delete struct {
+				IDs []string `json:"ids"`
+			} `json:"delete"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		for _, id := range req.Delete.IDs {
+			delete(s.rules, id)
+		}
+
+		added := []StreamRule{}
+
+		for _, rule := range req.Add {
+			s.nextID++
+			rule.ID = fmt.Sprint(s.nextID)
+			s.rules[rule.ID] = rule
+			added = append(added, rule)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": added})
+	case r.URL.Path == streamPath:
+		if r.URL.Query().Get("expansions") != "author_id" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		for _, p := range s.payloads {
+			writeStreamMessage(w, "")
+			writeStreamMessage(w, p)
+		}
+
+		s.payloads = nil
+		s.mu.Unlock()
+		<-r.Context().Done()
+		s.mu.Lock()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestStreamV2ClientSyncFollowRules(t *testing.T) {
+	stub := &stubStreamV2Server{
+		rules: map[string]StreamRule{
+			"a": {ID: "a", Value: "from:1", Tag: managedRuleTag},
+			"b": {ID: "b", Value: "from:2", Tag: managedRuleTag},
+			"c": {ID: "c", Value: "from:2", Tag: "other"},
+		},
+	}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	c := NewStreamV2Client("token", WithV2BaseURL(server.URL))
+
+	require.NoError(t, c.SyncFollowRules(context.Background(), []string{"1", "3"}))
+
+	rules, err := c.GetRules(context.Background())
+	require.NoError(t, err)
+
+	values := map[string]string{}
+	for _, r := range rules {
+		values[r.Value+"/"+r.Tag] = r.ID
+	}
+
+	assert.Len(t, values, 3)
+	assert.Equal(t, "a", values["from:1/"+managedRuleTag])
+	assert.Contains(t, values, "from:3/"+managedRuleTag)
+	assert.Equal(t, "c", values["from:2/other"])
+
+	_, err = NewStreamV2Client("wrong", WithV2BaseURL(server.URL)).GetRules(context.Background())
+	assert.ErrorIs(t, err, errUnexpectedStatusCode)
+}
+
+func TestManagerWithStreamV2Client(t *testing.T) {
+	listing := "Starting today, inbound transfers for SOL are now available in the regions where trading is supported. Traders cannot place orders and no orders will be filled. Trading will begin on or after 9AM PT on Monday May 24, if liquidity conditions are met."
+	text, err := json.Marshal(listing)
+	require.NoError(t, err)
+
+	stub := &stubStreamV2Server{
+		rules: map[string]StreamRule{},
+		payloads: []string{
+			fmt.Sprintf(`{"data":{"id":"10","text":%s,"author_id":"%s","referenced_tweets":[{"type":"retweeted","id":"9"}]},"includes":{"users":[{"id":"%s","username":"CoinbasePro"}]}}`, text, CoinbaseProTwitterUserID, CoinbaseProTwitterUserID),
+			fmt.Sprintf(`{"data":{"id":"11","text":%s,"author_id":"%s","in_reply_to_user_id":"5"},"includes":{"users":[{"id":"%s","username":"CoinbasePro"}]}}`, text, CoinbaseProTwitterUserID, CoinbaseProTwitterUserID),
+			fmt.Sprintf(`{"data":{"id":"12","text":%s,"author_id":"%s"},"includes":{"users":[{"id":"%s","username":"CoinbasePro"}]}}`, text, CoinbaseProTwitterUserID, CoinbaseProTwitterUserID),
+		},
+	}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	m := NewManager(
+		nil,
+		[]string{CoinbaseProTwitterUserID},
+		coin.Symbols{"SOL": {}},
+		WithStreamV2Client(NewStreamV2Client("token", WithV2BaseURL(server.URL))),
+	)
+
+	signalCh, err := m.SubscribeSignalChannel()
+	require.NoError(t, err)
+
+	select {
+	case s := <-signalCh:
+		assert.Equal(t, api.BuySignal{Symbol: "SOL", Source: "https://twitter.com/CoinbasePro/status/12", Stage: api.SignalStageTransfers, Side: api.SignalSideBuy}, s.BuySignal())
+		assert.Equal(t, api.SourceKindTwitter, s.SourceKind)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for buy signal")
+	}
+
+	stub.mu.Lock()
+	assert.Len(t, stub.rules, 1)
+	stub.mu.Unlock()
+
+	m.Stop()
+
+	_, ok := <-signalCh
+	assert.False(t, ok)
+}
+
+func TestParseStreamV2Tweet(t *testing.T) {
+	tw, err := parseStreamV2Tweet([]byte(`{"data":{"id":"2","text":"hi","author_id":"7","in_reply_to_user_id":"8"},"includes":{"users":[{"id":"7","username":"alice"}]}}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), tw.ID)
+	assert.Equal(t, "hi", tw.Text)
+	assert.Equal(t, "7", tw.User.IDStr)
+	assert.Equal(t, "alice", tw.User.ScreenName)
+	assert.True(t, isReply(tw))
+	assert.False(t, isRetweet(tw))
+
+	_, err = parseStreamV2Tweet([]byte(`{`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReplayWindow = 5 * time.Minute
+	defaultMaxBodyBytes = 64 * 1024
+)
+
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	replayWindow time.Duration
+	maxBodyBytes int64
+	logger       *zap.Logger
+}
+
+func newDefaultOptions() options {
+	return options{
+		replayWindow: defaultReplayWindow,
+		maxBodyBytes: defaultMaxBodyBytes,
+		logger:       zap.NewNop(),
+	}
+}
+
+type replayWindowOption time.Duration
+
+func (c replayWindowOption) apply(opts *options) {
+	opts.replayWindow = time.Duration(c)
+}
+
+// WithReplayWindow sets how far the timestamp of a request may be from now, a signature is only
+// accepted once within the window.
+func WithReplayWindow(d time.Duration) Option {
+	return replayWindowOption(d)
+}
+
+type maxBodyBytesOption int64
+
+func (c maxBodyBytesOption) apply(opts *options) {
+	opts.maxBodyBytes = int64(c)
+}
+
+func WithMaxBodyBytes(n int64) Option {
+	return maxBodyBytesOption(n)
+}
+
+type loggerOption struct {
+	logger *zap.Logger
+}
+
+func (c loggerOption) apply(opts *options) {
+	opts.logger = c.logger
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return loggerOption{logger: logger}
+}
@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"go.uber.org/zap"
+)
+
+const (
+	SourceName = "webhook"
+
+	SignalsPath     = "/signals"
+	SignatureHeader = "X-Ctrade-Signature"
+	TimestampHeader = "X-Ctrade-Timestamp"
+
+	signaturePrefix = "sha256="
+	shutdownTimeout = 5 * time.Second
+)
+
+var (
+	errInvalidTimestamp = errors.New("invalid timestamp")
+	errExpiredTimestamp = errors.New("timestamp out of replay window")
+	errInvalidSignature = errors.New("invalid signature")
+	errReplayedRequest  = errors.New("replayed request")
+)
+
+// Server accepts signals from other detectors. A request is signed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" with the shared secret, where timestamp is the unix time in seconds.
+type Server struct {
+	addr   string
+	secret []byte
+	opts   options
+
+	mu             sync.Mutex
+	seenSignatures map[string]time.Time
+	signalCh       chan api.Signal
+	httpServer     *http.Server
+	done           chan struct{}
+	stopped        bool
+	// the handlers sending on signalCh, it is closed once they return
+	sending sync.WaitGroup
+
+	now func() time.Time
+}
+
+func NewServer(addr string, secret string, opts ...Option) *Server {
+	options := newDefaultOptions()
+	for _, o := range opts {
+		o.apply(&options)
+	}
+
+	return &Server{
+		addr:           addr,
+		secret:         []byte(secret),
+		opts:           options,
+		seenSignatures: make(map[string]time.Time),
+		signalCh:       make(chan api.Signal),
+		done:           make(chan struct{}),
+		now:            time.Now,
+	}
+}
+
+func (s *Server) Name() string {
+	return SourceName
+}
+
+// Start listens on the address and serves the signals endpoint in the background.
+func (s *Server) Start() (<-chan api.Signal, error) {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen webhook: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(SignalsPath, s)
+
+	s.mu.Lock()
+	s.httpServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+	s.mu.Unlock()
+
+	go func() {
+		if err := s.httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.opts.logger.Error("Webhook server stopped", zap.Error(err))
+		}
+	}()
+
+	s.opts.logger.Info("Webhook server listening", zap.String("addr", ln.Addr().String()))
+
+	return s.signalCh, nil
+}
+
+// Stop shuts down the server and closes the signal channel once no handler can send on it anymore, a handler
+// still running after the shutdown timeout responds that the server is stopping.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.stopped = true
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	close(s.done)
+
+	if httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := httpServer.Shutdown(ctx); err != nil {
+			s.opts.logger.Error("Fail to shutdown webhook server", zap.Error(err))
+		}
+	}
+
+	s.sending.Wait()
+	close(s.signalCh)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.opts.maxBodyBytes))
+	if err != nil {
+		http.Error(w, "read body", http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	if err := s.verify(r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body); err != nil {
+		s.opts.logger.Warn("Rejected webhook request", zap.String("remoteAddr", r.RemoteAddr), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	var signal api.Signal
+	if err := json.Unmarshal(body, &signal); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// the kind claimed by the sender is not trusted, the signal comes through the webhook
+	signal.SourceKind = api.SourceKindWebhook
+
+	if err := signal.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// a signal without the detection time is timed by its arrival
+	if signal.DetectedAt.IsZero() {
+		signal.DetectedAt = s.now()
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		http.Error(w, "server stopping", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	s.sending.Add(1)
+	s.mu.Unlock()
+
+	defer s.sending.Done()
+
+	select {
+	case s.signalCh <- signal:
+		w.WriteHeader(http.StatusAccepted)
+	case <-s.done:
+		http.Error(w, "server stopping", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
+
+// verify checks the timestamp is within the replay window, the signature matches, and the signature
+// has not been accepted before.
+func (s *Server) verify(timestamp string, signature string, body []byte) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidTimestamp
+	}
+
+	now := s.now()
+	sentAt := time.Unix(ts, 0)
+
+	if sentAt.Before(now.Add(-s.opts.replayWindow)) || sentAt.After(now.Add(s.opts.replayWindow)) {
+		return errExpiredTimestamp
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !hmac.Equal(got, Sign(s.secret, timestamp, body)) {
+		return errInvalidSignature
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sig, seenAt := range s.seenSignatures {
+		if now.Sub(seenAt) > 2*s.opts.replayWindow {
+			delete(s.seenSignatures, sig)
+		}
+	}
+
+	key := hex.EncodeToString(got)
+	if _, ok := s.seenSignatures[key]; ok {
+		return errReplayedRequest
+	}
+
+	s.seenSignatures[key] = now
+
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 of "<timestamp>.<body>" with the secret.
+func Sign(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return mac.Sum(nil)
+}
+
+// SignatureHeaderValue formats the signature for the signature header.
+func SignatureHeaderValue(signature []byte) string {
+	return signaturePrefix + hex.EncodeToString(signature)
+}
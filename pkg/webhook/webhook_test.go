@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lht102/ctrade/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "secret"
+
+func newSignedRequest(t *testing.T, url string, secret string, sentAt time.Time, body string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url+SignalsPath, bytes.NewBufferString(body))
+	require.NoError(t, err)
+
+	ts := strconv.FormatInt(sentAt.Unix(), 10)
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(SignatureHeader, SignatureHeaderValue(Sign([]byte(secret), ts, []byte(body))))
+
+	return req
+}
+
+func TestServer(t *testing.T) {
+	now := time.Date(2021, 6, 17, 16, 0, 0, 0, time.UTC)
+
+	s := NewServer("", testSecret)
+	s.now = func() time.Time { return now }
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	received := make(chan api.Signal, 10)
+
+	go func() {
+		for v := range s.signalCh {
+			received <- v
+		}
+	}()
+
+	body := `{"symbol":"SOL","source":"detector-1"}`
+
+	testCases := []struct {
+		req    *http.Request
+		status int
+	}{
+		{req: newSignedRequest(t, server.URL, testSecret, now, body), status: http.StatusAccepted},
+		{req: newSignedRequest(t, server.URL, testSecret, now, body), status: http.StatusUnauthorized},
+		{req: newSignedRequest(t, server.URL, "wrong", now.Add(time.Second), body), status: http.StatusUnauthorized},
+		{req: newSignedRequest(t, server.URL, testSecret, now.Add(-10*time.Minute), body), status: http.StatusUnauthorized},
+		{req: newSignedRequest(t, server.URL, testSecret, now.Add(2*time.Second), `{"symbol":""}`), status: http.StatusBadRequest},
+		{req: newSignedRequest(t, server.URL, testSecret, now.Add(3*time.Second), `{`), status: http.StatusBadRequest},
+		{
+			req:    newSignedRequest(t, server.URL, testSecret, now.Add(4*time.Second), `{"version":1,"symbol":"TORN","side":"sell","sourceKind":"twitter","source":"detector-2","confidence":0.9}`),
+			status: http.StatusAccepted,
+		},
+	}
+
+	for i, tc := range testCases {
+		tc := tc
+
+		t.Run("Test "+strconv.Itoa(i), func(t *testing.T) {
+			resp, err := http.DefaultClient.Do(tc.req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+
+	resp, err := http.Get(server.URL + SignalsPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	assert.Equal(t, api.Signal{
+		Version:    api.SignalVersion,
+		Symbol:     "SOL",
+		Side:       api.SignalSideBuy,
+		SourceKind: api.SourceKindWebhook,
+		Source:     "detector-1",
+		DetectedAt: now,
+	}, <-received)
+	assert.Equal(t, api.Signal{
+		Version:    api.SignalVersion,
+		Symbol:     "TORN",
+		Side:       api.SignalSideSell,
+		SourceKind: api.SourceKindWebhook,
+		Source:     "detector-2",
+		DetectedAt: now,
+		Confidence: 0.9,
+	}, <-received)
+	assert.Empty(t, received)
+}
+
+func TestServerStartStop(t *testing.T) {
+	s := NewServer("127.0.0.1:0", testSecret)
+
+	ch, err := s.Start()
+	require.NoError(t, err)
+
+	s.Stop()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestServerStopWithPendingSignal(t *testing.T) {
+	now := time.Now()
+
+	s := NewServer("", testSecret)
+	s.now = func() time.Time { return now }
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	statusCh := make(chan int, 1)
+
+	go func() {
+		resp, err := http.DefaultClient.Do(newSignedRequest(t, server.URL, testSecret, now, `{"symbol":"SOL"}`))
+		if err != nil {
+			statusCh <- 0
+
+			return
+		}
+
+		resp.Body.Close()
+		statusCh <- resp.StatusCode
+	}()
+
+	// nothing receives the signal, so the handler is blocked on sending it
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+	s.Stop()
+
+	assert.Equal(t, http.StatusServiceUnavailable, <-statusCh)
+
+	_, ok := <-s.signalCh
+	assert.False(t, ok)
+
+	resp, err := http.DefaultClient.Do(newSignedRequest(t, server.URL, testSecret, now.Add(time.Second), `{"symbol":"SOL"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}